@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// accessLogger writes one structured entry per request,
+// independently of the application log level
+type accessLogger struct {
+	logger *slog.Logger
+	fields []string  // Optional fields to include, from configs.AccessLogFields
+	output io.Closer // Rotating log file, nil when writing to stdout
+}
+
+// newAccessLogger creates the access logger writing entries in format to the
+// configured file, or to stdout when no file is set
+func newAccessLogger(config configs.AccessLogConfig, format string) (*accessLogger, error) {
+	a := &accessLogger{fields: config.GetFields()}
+
+	var w io.Writer = os.Stdout
+	if config.File != "" {
+		// Open the file up front so a bad path fails at startup, not on the first request
+		file, err := os.OpenFile(config.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		file.Close()
+
+		rotating := &lumberjack.Logger{
+			Filename:   config.File,
+			MaxSize:    config.MaxSizeMB,
+			MaxBackups: config.MaxBackups,
+			MaxAge:     config.MaxAgeDays,
+			Compress:   config.Compress,
+		}
+		w = rotating
+		a.output = rotating
+	}
+
+	a.logger = slog.New(newLogHandler(w, format, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	return a, nil
+}
+
+// log records a request. The route and condition are left out when no route
+// matched, such as for 404 and 405 responses and the metrics endpoint.
+func (a *accessLogger) log(ctx *fasthttp.RequestCtx, info *requestInfo, elapsed time.Duration) {
+	attrs := make([]slog.Attr, 0, 2+len(a.fields))
+	attrs = append(attrs,
+		slog.String("method", string(ctx.Method())),
+		slog.String("path", string(ctx.Path())),
+	)
+
+	for _, field := range a.fields {
+		switch field {
+		case "route":
+			if info.route != "" {
+				attrs = append(attrs, slog.String("route", info.route))
+			}
+		case "status":
+			attrs = append(attrs, slog.Int("status", ctx.Response.StatusCode()))
+		case "latency":
+			attrs = append(attrs, slog.Float64("latency_ms", float64(elapsed.Microseconds())/1000))
+		case "bytes":
+			// Reading a streamed body would buffer it, use its length instead.
+			// A chunked stream is written after the request is logged, so its
+			// size is not known and the field is left out.
+			size := ctx.Response.Header.ContentLength()
+			if !ctx.Response.IsBodyStream() {
+				size = len(ctx.Response.Body())
+			}
+			if size >= 0 {
+				attrs = append(attrs, slog.Int("bytes", size))
+			}
+		case "client_ip":
+			attrs = append(attrs, slog.String("client_ip", info.clientIP.String()))
+		case "user_agent":
+			attrs = append(attrs, slog.String("user_agent", string(ctx.UserAgent())))
+		case "condition":
+			if info.route == "" {
+				continue
+			}
+			condition := "default"
+			if info.condition >= 0 {
+				condition = strconv.Itoa(info.condition)
+			}
+			attrs = append(attrs, slog.String("condition", condition))
+		}
+	}
+
+	a.logger.LogAttrs(ctx, slog.LevelInfo, "access", attrs...)
+}
+
+// Close closes the log file, if any
+func (a *accessLogger) Close() error {
+	if a.output == nil {
+		return nil
+	}
+	return a.output.Close()
+}
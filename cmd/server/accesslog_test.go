@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+func TestNewLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(newLogHandler(&buf, "JSON", nil)).Info("hello", "key", "value")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON log line, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "hello" || entry["key"] != "value" {
+		t.Errorf("Expected msg and key in JSON entry, got %v", entry)
+	}
+
+	buf.Reset()
+	slog.New(newLogHandler(&buf, "text", nil)).Info("hello", "key", "value")
+	if !strings.Contains(buf.String(), "msg=hello key=value") {
+		t.Errorf("Expected a text log line, got %q", buf.String())
+	}
+}
+
+func TestServer_AccessLog(t *testing.T) {
+	config := &configs.ServerConfig{
+		Routes: []configs.Route{
+			{
+				Path:         "/api/{id}",
+				Method:       "GET",
+				ResponseBody: "default",
+				Conditions: []configs.RouteCondition{
+					{
+						HeaderMatch:    map[string]string{"X-Test": "teapot"},
+						ResponseStatus: 418,
+					},
+				},
+			},
+			{Path: "/large", Method: "GET", ResponseGenerate: configs.GenerateConfig{Type: configs.GenerateRandom, Size: "1MB"}},
+			{Path: "/chunked", Method: "GET", ResponseGenerate: configs.GenerateConfig{Type: configs.GenerateJSON, Count: 5000}},
+		},
+	}
+
+	// readEntries returns the JSON entries written to file
+	readEntries := func(t *testing.T, file string) []map[string]any {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read access log: %v", err)
+		}
+		var entries []map[string]any
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			var entry map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				t.Fatalf("Expected JSON access log lines, got %q: %v", scanner.Text(), err)
+			}
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	request := func(server *Server, uri string, headers map[string]string) {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(uri)
+		ctx.Request.Header.SetMethod("GET")
+		for key, value := range headers {
+			ctx.Request.Header.Set(key, value)
+		}
+		server.Handler(ctx)
+	}
+
+	t.Run("all fields", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "access.log")
+		accessLog, err := newAccessLogger(configs.AccessLogConfig{File: file, MaxSizeMB: 1}, "json")
+		if err != nil {
+			t.Fatalf("Failed to create access logger: %v", err)
+		}
+		defer accessLog.Close()
+
+		server := &Server{config: config, accessLog: accessLog}
+		if err := server.initializeRouter(); err != nil {
+			t.Fatalf("initializeRouter() error = %v", err)
+		}
+		request(server, "/api/1", map[string]string{"X-Test": "teapot", "User-Agent": "tests"})
+
+		entries := readEntries(t, file)
+		if len(entries) != 1 {
+			t.Fatalf("Expected 1 access log entry, got %d", len(entries))
+		}
+		entry := entries[0]
+
+		expected := map[string]any{
+			"level":      "INFO",
+			"msg":        "access",
+			"method":     "GET",
+			"path":       "/api/1",
+			"route":      "/api/{id}",
+			"status":     float64(418),
+			"bytes":      float64(0),
+			"client_ip":  "0.0.0.0",
+			"user_agent": "tests",
+			"condition":  "0",
+		}
+		for key, value := range expected {
+			if entry[key] != value {
+				t.Errorf("Expected %s=%v, got %v", key, value, entry[key])
+			}
+		}
+		if _, ok := entry["latency_ms"].(float64); !ok {
+			t.Errorf("Expected numeric latency_ms, got %v", entry["latency_ms"])
+		}
+	})
+
+	t.Run("selected fields", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "access.log")
+		accessLog, err := newAccessLogger(configs.AccessLogConfig{
+			File:      file,
+			MaxSizeMB: 1,
+			Fields:    []string{"status", "condition"},
+		}, "json")
+		if err != nil {
+			t.Fatalf("Failed to create access logger: %v", err)
+		}
+		defer accessLog.Close()
+
+		server := &Server{config: config, accessLog: accessLog}
+		if err := server.initializeRouter(); err != nil {
+			t.Fatalf("initializeRouter() error = %v", err)
+		}
+		request(server, "/api/2", nil)
+
+		entries := readEntries(t, file)
+		if len(entries) != 1 {
+			t.Fatalf("Expected 1 access log entry, got %d", len(entries))
+		}
+		entry := entries[0]
+		if entry["status"] != float64(200) || entry["condition"] != "default" || entry["path"] != "/api/2" {
+			t.Errorf("Expected status, condition and path, got %v", entry)
+		}
+		for _, key := range []string{"route", "latency_ms", "bytes", "client_ip", "user_agent"} {
+			if _, ok := entry[key]; ok {
+				t.Errorf("Expected %s to be omitted, got %v", key, entry[key])
+			}
+		}
+	})
+
+	t.Run("streamed bodies", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "access.log")
+		accessLog, err := newAccessLogger(configs.AccessLogConfig{File: file, MaxSizeMB: 1, Fields: []string{"bytes"}}, "json")
+		if err != nil {
+			t.Fatalf("Failed to create access logger: %v", err)
+		}
+		defer accessLog.Close()
+
+		server := &Server{config: config, accessLog: accessLog}
+		if err := server.initializeRouter(); err != nil {
+			t.Fatalf("initializeRouter() error = %v", err)
+		}
+		request(server, "/large", nil)
+		request(server, "/chunked", nil)
+
+		entries := readEntries(t, file)
+		if len(entries) != 2 {
+			t.Fatalf("Expected 2 access log entries, got %d", len(entries))
+		}
+		if entries[0]["bytes"] != float64(1<<20) {
+			t.Errorf("Expected the Content-Length of a streamed body, got %v", entries[0]["bytes"])
+		}
+		if size, ok := entries[1]["bytes"]; ok {
+			t.Errorf("Expected bytes to be omitted for a chunked body, got %v", size)
+		}
+	})
+
+	t.Run("unmatched requests", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "access.log")
+		accessLog, err := newAccessLogger(configs.AccessLogConfig{File: file, MaxSizeMB: 1}, "json")
+		if err != nil {
+			t.Fatalf("Failed to create access logger: %v", err)
+		}
+		defer accessLog.Close()
+
+		metricsConfig := *config
+		metricsConfig.Metrics = configs.MetricsConfig{Enabled: true, Path: "/metrics"}
+		server := &Server{config: &metricsConfig, accessLog: accessLog, metrics: newMetrics()}
+		if err := server.initializeRouter(); err != nil {
+			t.Fatalf("initializeRouter() error = %v", err)
+		}
+		request(server, "/missing", nil)
+		request(server, "/metrics", nil)
+
+		entries := readEntries(t, file)
+		if len(entries) != 2 {
+			t.Fatalf("Expected 2 access log entries, got %d", len(entries))
+		}
+		if entries[0]["status"] != float64(404) || entries[0]["path"] != "/missing" {
+			t.Errorf("Expected a 404 entry for /missing, got %v", entries[0])
+		}
+		if entries[1]["status"] != float64(200) || entries[1]["path"] != "/metrics" {
+			t.Errorf("Expected a 200 entry for /metrics, got %v", entries[1])
+		}
+		for _, entry := range entries {
+			for _, key := range []string{"route", "condition"} {
+				if _, ok := entry[key]; ok {
+					t.Errorf("Expected %s to be omitted without a matched route, got %v", key, entry[key])
+				}
+			}
+		}
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "access.log")
+		accessLog, err := newAccessLogger(configs.AccessLogConfig{File: file, MaxSizeMB: 1}, "json")
+		if err != nil {
+			t.Fatalf("Failed to create access logger: %v", err)
+		}
+		defer accessLog.Close()
+
+		server := &Server{config: config, accessLog: accessLog}
+		if err := server.initializeRouter(); err != nil {
+			t.Fatalf("initializeRouter() error = %v", err)
+		}
+		userAgent := strings.Repeat("a", 100<<10)
+		for i := 0; i < 12; i++ {
+			request(server, "/api/3", map[string]string{"User-Agent": userAgent})
+		}
+
+		files, err := filepath.Glob(filepath.Join(dir, "access-*.log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) == 0 {
+			t.Error("Expected the access log to be rotated once it exceeded max_size_mb")
+		}
+	})
+
+	t.Run("unwritable file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "missing", "access.log")
+		if _, err := newAccessLogger(configs.AccessLogConfig{File: file}, "json"); err == nil {
+			t.Error("Expected error for a file in a missing directory")
+		}
+	})
+}
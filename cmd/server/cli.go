@@ -0,0 +1,249 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+// command describes a CLI subcommand such as "validate" or "routes"
+type command struct {
+	name  string
+	usage string
+	run   func(args []string, stdout, stderr io.Writer) int
+}
+
+// commands lists every subcommand in the order shown by the usage message
+var commands = []command{
+	{
+		name:  "serve",
+		usage: "Start the server (default when no command is given)",
+		run: func(args []string, stdout, stderr io.Writer) int {
+			return runServe(args, stderr)
+		},
+	},
+	{
+		name:  "validate",
+		usage: "Load and validate a configuration file",
+		run:   runValidate,
+	},
+	{
+		name:  "routes",
+		usage: "Print the effective route table",
+		run:   runRoutes,
+	},
+	{
+		name:  "lint",
+		usage: "Warn about unreachable conditions, invalid JSON bodies, cookies browsers reject and options that have no effect",
+		run:   runLint,
+	},
+	{
+		name:  "schema",
+		usage: "Print the JSON Schema of the configuration file",
+		run:   runSchema,
+	},
+}
+
+// run dispatches the command line to the matching subcommand and returns the
+// process exit code. Arguments that do not start with a command name are
+// passed to "serve" so that "echo-server -config x.yaml" keeps working.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		if len(args) > 0 && (args[0] == "-h" || args[0] == "-help" || args[0] == "--help") {
+			printUsage(stderr)
+			return 0
+		}
+		return runServe(args, stderr)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout, stderr)
+		}
+	}
+
+	if args[0] == "help" {
+		printUsage(stdout)
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+	printUsage(stderr)
+	return 2
+}
+
+// printUsage writes the list of available subcommands
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: echo-server [command] [-config path]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.name, cmd.usage)
+	}
+	tw.Flush()
+}
+
+// configPaths collects every -config flag so the option can be repeated
+type configPaths []string
+
+// String implements flag.Value
+func (p *configPaths) String() string {
+	return strings.Join(*p, ",")
+}
+
+// Set implements flag.Value by appending another path
+func (p *configPaths) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+// orDefault returns the configured paths, or config.yaml when none were given
+func (p configPaths) orDefault() []string {
+	if len(p) == 0 {
+		return []string{"config.yaml"}
+	}
+	return p
+}
+
+// newConfigFlagSet creates a flag set with the repeatable -config flag shared
+// by all subcommands
+func newConfigFlagSet(name string, stderr io.Writer) (*flag.FlagSet, *configPaths) {
+	var paths configPaths
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Var(&paths, "config", "Path to a configuration file or directory, - for stdin, or an http(s) URL (repeatable, default config.yaml)")
+	return flags, &paths
+}
+
+// parseConfigFlags parses the -config flag shared by all subcommands and
+// returns the configured paths, defaulting to config.yaml
+func parseConfigFlags(name string, args []string, stderr io.Writer) ([]string, error) {
+	flags, paths := newConfigFlagSet(name, stderr)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	return paths.orDefault(), nil
+}
+
+// loadConfigFromFlags parses the -config flags and loads the configuration
+// they point to. Errors are reported on stderr and the returned exit code is
+// non-zero when config is nil.
+func loadConfigFromFlags(name string, args []string, stderr io.Writer) (*configs.ServerConfig, int) {
+	paths, err := parseConfigFlags(name, args, stderr)
+	if err != nil {
+		return nil, 2
+	}
+
+	config, err := configs.LoadConfigs(paths...)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", strings.Join(paths, ", "), err)
+		return nil, 1
+	}
+
+	return config, 0
+}
+
+// runValidate loads the configuration and reports whether it is valid
+func runValidate(args []string, stdout, stderr io.Writer) int {
+	config, code := loadConfigFromFlags("validate", args, stderr)
+	if config == nil {
+		return code
+	}
+
+	fmt.Fprintf(stdout, "OK: %d routes\n", len(config.Routes))
+	return 0
+}
+
+// runRoutes prints every configured route with its defaults applied
+func runRoutes(args []string, stdout, stderr io.Writer) int {
+	config, code := loadConfigFromFlags("routes", args, stderr)
+	if config == nil {
+		return code
+	}
+
+	// The host and listeners columns only appear when they are used
+	withHosts := slices.ContainsFunc(config.Routes, func(r configs.Route) bool { return r.Host != "" })
+	withListeners := len(config.Listeners) > 0
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	header := "METHOD\tPATH\tSTATUS\tCONDITIONS\tDUMP\tSOURCE"
+	if withHosts {
+		header += "\tHOST"
+	}
+	if withListeners {
+		header += "\tLISTENERS"
+	}
+	fmt.Fprintln(tw, header)
+	for _, route := range config.Routes {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%t\t%s",
+			strings.ToUpper(route.GetMethod()),
+			route.Path,
+			route.GetResponseStatus(),
+			len(route.Conditions),
+			route.GetResponseDump(),
+			route.Source())
+		if withHosts {
+			host := "*"
+			if route.Host != "" {
+				host = route.Host
+			}
+			fmt.Fprintf(tw, "\t%s", host)
+		}
+		if withListeners {
+			listeners := "*"
+			if len(route.Listeners) > 0 {
+				listeners = strings.Join(route.Listeners, ",")
+			}
+			fmt.Fprintf(tw, "\t%s", listeners)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+
+	return 0
+}
+
+// runLint loads the configuration and prints lint warnings. It exits with 1
+// when any warning is found so it can gate CI pipelines.
+func runLint(args []string, stdout, stderr io.Writer) int {
+	config, code := loadConfigFromFlags("lint", args, stderr)
+	if config == nil {
+		return code
+	}
+
+	warnings := configs.Lint(config)
+	for _, warning := range warnings {
+		fmt.Fprintf(stdout, "warning: %s\n", warning)
+	}
+
+	if len(warnings) > 0 {
+		return 1
+	}
+
+	fmt.Fprintln(stdout, "No issues found")
+	return 0
+}
+
+// runSchema prints the JSON Schema generated from the configuration types
+func runSchema(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("schema", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	schema, err := configs.JSONSchema()
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to generate schema: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintln(stdout, string(schema))
+	return 0
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return configFile
+}
+
+func TestRun_Subcommands(t *testing.T) {
+	validConfig := writeTestConfig(t, `routes:
+  - path: "/health"
+    response_body: "OK"
+  - path: "/api/users"
+    method: "POST"
+    response_status: 201
+`)
+	invalidConfig := writeTestConfig(t, `routes:
+  - path: "/test"
+    method: "INVALID"
+`)
+	extraConfig := writeTestConfig(t, `routes:
+  - path: "/extra"
+`)
+	listenersConfig := writeTestConfig(t, `listeners:
+  - name: payments
+    address: ":9001"
+routes:
+  - path: "/charge"
+    listeners: [payments]
+    host: pay.example.test
+  - path: "/health"
+`)
+	lintConfig := writeTestConfig(t, `routes:
+  - path: "/api/users"
+    response_body: "{broken"
+    response_header:
+      Content-Type: "application/json"
+`)
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedCode   int
+		expectedStdout []string
+		expectedStderr []string
+	}{
+		{
+			name:           "validate valid config",
+			args:           []string{"validate", "-config", validConfig},
+			expectedCode:   0,
+			expectedStdout: []string{"OK: 2 routes"},
+		},
+		{
+			name:           "validate invalid config",
+			args:           []string{"validate", "-config", invalidConfig},
+			expectedCode:   1,
+			expectedStderr: []string{"invalid HTTP method 'INVALID'"},
+		},
+		{
+			name:           "routes prints effective table",
+			args:           []string{"routes", "-config", validConfig},
+			expectedCode:   0,
+			expectedStdout: []string{"METHOD", "GET     /health", "POST    /api/users  201"},
+		},
+		{
+			name:           "routes with hosts and listeners",
+			args:           []string{"routes", "-config", listenersConfig},
+			expectedCode:   0,
+			expectedStdout: []string{"HOST", "LISTENERS", "pay.example.test  payments", "*                 *"},
+		},
+		{
+			name:           "lint clean config",
+			args:           []string{"lint", "-config", validConfig},
+			expectedCode:   0,
+			expectedStdout: []string{"No issues found"},
+		},
+		{
+			name:           "lint reports warnings",
+			args:           []string{"lint", "-config", lintConfig},
+			expectedCode:   1,
+			expectedStdout: []string{"warning: route 0 (" + lintConfig + ":2): response_body is not valid JSON"},
+		},
+		{
+			name:           "repeated config flags are merged",
+			args:           []string{"validate", "-config", validConfig, "-config", extraConfig},
+			expectedCode:   0,
+			expectedStdout: []string{"OK: 3 routes"},
+		},
+		{
+			name:           "schema",
+			args:           []string{"schema"},
+			expectedCode:   0,
+			expectedStdout: []string{`"$schema": "http://json-schema.org/draft-07/schema#"`, `"response_status"`},
+		},
+		{
+			name:           "serve rejects refreshing stdin",
+			args:           []string{"serve", "-config", "-", "-config-refresh", "10s"},
+			expectedCode:   2,
+			expectedStderr: []string{"-config-refresh cannot be used with a configuration read from stdin"},
+		},
+		{
+			name:           "unknown command",
+			args:           []string{"bogus"},
+			expectedCode:   2,
+			expectedStderr: []string{`unknown command "bogus"`, "validate"},
+		},
+		{
+			name:           "help",
+			args:           []string{"help"},
+			expectedCode:   0,
+			expectedStdout: []string{"serve", "validate", "routes", "lint"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, &stdout, &stderr)
+
+			if code != tt.expectedCode {
+				t.Errorf("Expected exit code %d, got %d (stderr: %q)", tt.expectedCode, code, stderr.String())
+			}
+			for _, expected := range tt.expectedStdout {
+				if !strings.Contains(stdout.String(), expected) {
+					t.Errorf("Expected stdout to contain %q, got %q", expected, stdout.String())
+				}
+			}
+			for _, expected := range tt.expectedStderr {
+				if !strings.Contains(stderr.String(), expected) {
+					t.Errorf("Expected stderr to contain %q, got %q", expected, stderr.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+// parseTrustedProxies parses the trusted_proxies setting. Invalid entries are
+// rejected when the config is loaded, so they are only skipped here.
+func parseTrustedProxies(proxies []string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, proxy := range proxies {
+		prefix, err := configs.ParsePrefix(proxy)
+		if err != nil {
+			continue
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes
+}
+
+// resolveClientIP returns the address of the client that sent the request.
+// When the peer is a trusted proxy, the Forwarded or X-Forwarded-For hops are
+// walked from the nearest one outwards and the first untrusted address is the
+// client. A hop that is not an IP address, such as "unknown", ends the walk.
+func resolveClientIP(ctx *fasthttp.RequestCtx, trusted []netip.Prefix) netip.Addr {
+	peer, _ := netip.AddrFromSlice(ctx.RemoteIP())
+	client := peer.Unmap()
+	if !isTrusted(client, trusted) {
+		return client
+	}
+
+	hops := forwardedHops(&ctx.Request.Header)
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseForwardedAddr(hops[i])
+		if !ok {
+			break
+		}
+		client = addr
+		if !isTrusted(addr, trusted) {
+			break
+		}
+	}
+	return client
+}
+
+// isTrusted reports whether addr is in one of the trusted prefixes
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedHops returns the client addresses recorded by proxies, from the
+// first proxy to the last. The standard Forwarded header takes precedence
+// over X-Forwarded-For when both are present.
+func forwardedHops(header *fasthttp.RequestHeader) []string {
+	var hops []string
+	for _, value := range header.PeekAll(fasthttp.HeaderForwarded) {
+		for _, element := range strings.Split(string(value), ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hops = append(hops, value)
+				}
+			}
+		}
+	}
+	if len(hops) > 0 {
+		return hops
+	}
+
+	for _, value := range header.PeekAll(fasthttp.HeaderXForwardedFor) {
+		hops = append(hops, strings.Split(string(value), ",")...)
+	}
+	return hops
+}
+
+// parseForwardedAddr parses a hop such as 192.0.2.1, 192.0.2.1:4711,
+// "[2001:db8::1]:4711" or 2001:db8::1, ignoring the port
+func parseForwardedAddr(value string) (netip.Addr, bool) {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	if addr, err := netip.ParseAddr(strings.Trim(value, "[]")); err == nil {
+		return addr.Unmap(), true
+	}
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	return netip.Addr{}, false
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"net"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+func TestResolveClientIP(t *testing.T) {
+	trusted := parseTrustedProxies([]string{"10.0.0.0/8", "2001:db8::1"})
+
+	tests := []struct {
+		name     string
+		peer     string
+		headers  [][2]string
+		expected string
+	}{
+		{"untrusted peer ignores headers", "203.0.113.7", [][2]string{{"X-Forwarded-For", "198.51.100.1"}}, "203.0.113.7"},
+		{"trusted peer without headers", "10.0.0.1", nil, "10.0.0.1"},
+		{"x-forwarded-for", "10.0.0.1", [][2]string{{"X-Forwarded-For", "198.51.100.1"}}, "198.51.100.1"},
+		{"skips trusted hops", "10.0.0.1", [][2]string{{"X-Forwarded-For", "198.51.100.1, 10.1.1.1,10.2.2.2"}}, "198.51.100.1"},
+		{"spoofed hops before the first untrusted are ignored", "10.0.0.1", [][2]string{{"X-Forwarded-For", "1.1.1.1, 198.51.100.1"}}, "198.51.100.1"},
+		{"repeated headers", "10.0.0.1", [][2]string{{"X-Forwarded-For", "198.51.100.1"}, {"X-Forwarded-For", "10.3.3.3"}}, "198.51.100.1"},
+		{"all hops trusted", "10.0.0.1", [][2]string{{"X-Forwarded-For", "10.9.9.9"}}, "10.9.9.9"},
+		{"forwarded", "10.0.0.1", [][2]string{{"Forwarded", `for=198.51.100.1;proto=https, for="[2001:db8::1]:4711"`}}, "198.51.100.1"},
+		{"forwarded ipv6", "10.0.0.1", [][2]string{{"Forwarded", `For="[2001:db8::cafe]:4711"`}}, "2001:db8::cafe"},
+		{"forwarded wins over x-forwarded-for", "10.0.0.1", [][2]string{{"Forwarded", "for=198.51.100.2"}, {"X-Forwarded-For", "198.51.100.1"}}, "198.51.100.2"},
+		{"obfuscated hop ends the walk", "10.0.0.1", [][2]string{{"Forwarded", "for=198.51.100.1, for=_hidden, for=10.4.4.4"}}, "10.4.4.4"},
+		{"hop with port", "10.0.0.1", [][2]string{{"X-Forwarded-For", "198.51.100.1:5000"}}, "198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req fasthttp.Request
+			for _, header := range tt.headers {
+				req.Header.Add(header[0], header[1])
+			}
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Init(&req, &net.TCPAddr{IP: net.ParseIP(tt.peer), Port: 1234}, nil)
+
+			if got := resolveClientIP(ctx, trusted).String(); got != tt.expected {
+				t.Errorf("Expected client IP %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestServer_ClientIPConditions(t *testing.T) {
+	config := &configs.ServerConfig{
+		TrustedProxies: []string{"10.0.0.1"},
+		Routes: []configs.Route{
+			{
+				Path:         "/geo",
+				Method:       "GET",
+				ResponseBody: "external",
+				Conditions: []configs.RouteCondition{
+					{
+						ClientIP:     &configs.ClientIPMatch{Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.0.0.1"}},
+						ResponseBody: "internal",
+					},
+				},
+			},
+		},
+	}
+	// Parse the client_ip entries like a loaded config
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	server := &Server{config: config}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		peer     string
+		xff      string
+		expected string
+	}{
+		{"internal caller", "10.2.3.4", "", "internal"},
+		{"external caller", "203.0.113.7", "", "external"},
+		{"internal caller through trusted proxy", "10.0.0.1", "10.5.5.5", "internal"},
+		{"external caller through trusted proxy", "10.0.0.1", "203.0.113.7", "external"},
+		{"trusted proxy itself is denied", "10.0.0.1", "", "external"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req fasthttp.Request
+			req.SetRequestURI("/geo")
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Init(&req, &net.TCPAddr{IP: net.ParseIP(tt.peer), Port: 1234}, nil)
+			server.Handler(ctx)
+
+			if got := string(ctx.Response.Body()); got != tt.expected {
+				t.Errorf("Expected %q response, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+// responseControls are the changes a client asked for with the request
+// controls other than delay
+type responseControls struct {
+	status      int         // Response status, 0 to keep the route's
+	headers     [][2]string // Response headers replacing the route's of the same name
+	size        int64       // Size of a generated body, -1 to keep the route's body
+	contentType string      // Response Content-Type, empty to keep the route's
+	fail        bool        // Answer with an injected 500 instead of the route's response
+}
+
+// headerNameRegexp matches a header name, which must be an HTTP token
+var headerNameRegexp = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// controlValues returns the values of a control from its request header, or
+// from its query parameter when the header is not sent, along with a label
+// naming where they came from for error messages
+func (s *Server) controlValues(ctx *fasthttp.RequestCtx, name string, source configs.ControlSource) ([]string, string) {
+	param, header := source.GetNames(name)
+
+	var values []string
+	if header != "" {
+		for _, value := range ctx.Request.Header.PeekAll(header) {
+			values = append(values, string(value))
+		}
+		if len(values) > 0 {
+			return values, header + " header"
+		}
+	}
+	if param != "" {
+		for _, value := range ctx.QueryArgs().PeekMulti(param) {
+			values = append(values, string(value))
+		}
+	}
+	return values, param + " parameter"
+}
+
+// parseControls reads the enabled status, header, size, content_type and
+// fail_rate controls from the request
+func (s *Server) parseControls(ctx *fasthttp.RequestCtx) (responseControls, error) {
+	controls := responseControls{size: -1}
+	config := &s.config.Controls
+	if !config.Enabled {
+		return controls, nil
+	}
+
+	if values, label := s.controlValues(ctx, configs.ControlStatus, config.Status); len(values) > 0 {
+		status, err := strconv.Atoi(values[0])
+		if err != nil || status < 100 || status > 599 {
+			return controls, fmt.Errorf("%s: expected a status code between 100 and 599, got '%s'", label, values[0])
+		}
+		controls.status = status
+	}
+
+	values, label := s.controlValues(ctx, configs.ControlHeader, config.Header)
+	for _, value := range values {
+		name, headerValue, ok := strings.Cut(value, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return controls, fmt.Errorf("%s: expected Name:value, got %q", label, value)
+		}
+		if !headerNameRegexp.MatchString(name) {
+			return controls, fmt.Errorf("%s: invalid header name %q", label, name)
+		}
+		// A CR or LF would end the header and start another one
+		headerValue = strings.TrimSpace(headerValue)
+		if strings.ContainsFunc(headerValue, func(r rune) bool { return r < ' ' && r != '\t' || r == 0x7f }) {
+			return controls, fmt.Errorf("%s: header value of %s contains control characters", label, name)
+		}
+		controls.headers = append(controls.headers, [2]string{name, headerValue})
+	}
+
+	if values, label := s.controlValues(ctx, configs.ControlSize, config.Size); len(values) > 0 {
+		size, err := configs.ParseSize(values[0])
+		if err != nil {
+			return controls, fmt.Errorf("%s: %v", label, err)
+		}
+		if maxSize := config.GetMaxSize(); size > maxSize {
+			return controls, fmt.Errorf("%s: %d bytes exceeds controls.max_size of %d bytes", label, size, maxSize)
+		}
+		controls.size = size
+	}
+
+	if values, _ := s.controlValues(ctx, configs.ControlContentType, config.ContentType); len(values) > 0 {
+		controls.contentType = values[0]
+	}
+
+	if values, label := s.controlValues(ctx, configs.ControlFailRate, config.FailRate); len(values) > 0 {
+		rate, err := strconv.ParseFloat(values[0], 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return controls, fmt.Errorf("%s: expected a rate between 0 and 1, got '%s'", label, values[0])
+		}
+		controls.fail = rand.Float64() < rate
+	}
+
+	return controls, nil
+}
+
+// applyHeaders sets the headers requested with the header control, replacing
+// headers of the same name set by the route
+func (c *responseControls) applyHeaders(ctx *fasthttp.RequestCtx) {
+	replaced := make(map[string]bool)
+	for _, header := range c.headers {
+		key := strings.ToLower(header[0])
+		if !replaced[key] {
+			ctx.Response.Header.Del(header[0])
+			replaced[key] = true
+		}
+		ctx.Response.Header.Add(header[0], header[1])
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+func TestServer_Controls(t *testing.T) {
+	routes := []configs.Route{
+		{
+			Path:           "/api",
+			Method:         "GET",
+			ResponseBody:   `{"ok": true}`,
+			ResponseHeader: map[string]configs.HeaderValues{"Content-Type": {"application/json"}, "X-Version": {"1"}},
+		},
+		{Path: "/dump", Method: "GET", ResponseDump: true},
+		{Path: "/echo", Method: "GET", ResponseEcho: configs.EchoConfig{Enabled: true}},
+	}
+	request := func(server *Server, uri string, headers map[string]string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(uri)
+		for name, value := range headers {
+			ctx.Request.Header.Set(name, value)
+		}
+		server.Handler(ctx)
+		return ctx
+	}
+	newServer := func(controls configs.ControlsConfig) *Server {
+		server := &Server{config: &configs.ServerConfig{Controls: controls, Routes: routes}}
+		if err := server.initializeRouter(); err != nil {
+			t.Fatalf("initializeRouter() error = %v", err)
+		}
+		return server
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		ctx := request(newServer(configs.ControlsConfig{}), "/api?status=503&size=10", nil)
+		if ctx.Response.StatusCode() != 200 || string(ctx.Response.Body()) != `{"ok": true}` {
+			t.Errorf("Expected the route's response, got %d %q", ctx.Response.StatusCode(), ctx.Response.Body())
+		}
+	})
+
+	server := newServer(configs.ControlsConfig{Enabled: true, MaxSize: "1KB"})
+
+	t.Run("status", func(t *testing.T) {
+		if got := request(server, "/api?status=503", nil).Response.StatusCode(); got != 503 {
+			t.Errorf("Expected status 503, got %d", got)
+		}
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		ctx := request(server, "/api?header=X-Foo:bar&header=X-Version:%202&header=X-Version:3", nil)
+		if got := string(ctx.Response.Header.Peek("X-Foo")); got != "bar" {
+			t.Errorf("Expected X-Foo: bar, got %q", got)
+		}
+		var versions []string
+		for _, value := range ctx.Response.Header.PeekAll("X-Version") {
+			versions = append(versions, string(value))
+		}
+		if strings.Join(versions, ",") != "2,3" {
+			t.Errorf("Expected X-Version 2 and 3 replacing the route's, got %v", versions)
+		}
+	})
+
+	t.Run("size and content type", func(t *testing.T) {
+		ctx := request(server, "/api?size=1KB&content_type=application/octet-stream", nil)
+		if got := len(ctx.Response.Body()); got != 1024 {
+			t.Errorf("Expected a 1024 byte body, got %d", got)
+		}
+		if !strings.HasPrefix(string(ctx.Response.Body()), fillerLine) {
+			t.Errorf("Expected the filler payload, got %q", ctx.Response.Body()[:20])
+		}
+		if got := string(ctx.Response.Header.ContentType()); got != "application/octet-stream" {
+			t.Errorf("Expected application/octet-stream, got %q", got)
+		}
+		if got := len(request(server, "/api?size=0", nil).Response.Body()); got != 0 {
+			t.Errorf("Expected an empty body, got %d bytes", got)
+		}
+	})
+
+	t.Run("size replaces the route's content type", func(t *testing.T) {
+		ctx := request(server, "/api?size=20", nil)
+		if got := string(ctx.Response.Header.ContentType()); got != "text/plain; charset=utf-8" {
+			t.Errorf("Expected text/plain for the generated body, got %q", got)
+		}
+	})
+
+	t.Run("size replaces dumps and echoes", func(t *testing.T) {
+		for _, uri := range []string{"/dump?size=20", "/echo?size=20"} {
+			ctx := request(server, uri, map[string]string{"Content-Type": "application/json", "Accept": "application/json"})
+			if got := string(ctx.Response.Body()); got != fillerLine[:20] {
+				t.Errorf("%s: expected 20 bytes of filler, got %q", uri, got)
+			}
+			if got := string(ctx.Response.Header.ContentType()); got != "text/plain; charset=utf-8" {
+				t.Errorf("%s: expected text/plain for the generated body, got %q", uri, got)
+			}
+			if got := ctx.Response.Header.Peek("Vary"); len(got) != 0 {
+				t.Errorf("%s: expected no dump negotiation, got Vary: %s", uri, got)
+			}
+		}
+	})
+
+	t.Run("fail rate", func(t *testing.T) {
+		ctx := request(server, "/api?fail_rate=1", nil)
+		if ctx.Response.StatusCode() != 500 || string(ctx.Response.Body()) != "Injected failure" {
+			t.Errorf("Expected an injected failure, got %d %q", ctx.Response.StatusCode(), ctx.Response.Body())
+		}
+		if got := request(server, "/api?fail_rate=0", nil).Response.StatusCode(); got != 200 {
+			t.Errorf("Expected no failure with fail_rate=0, got %d", got)
+		}
+	})
+
+	t.Run("header cannot split the response", func(t *testing.T) {
+		ctx := request(server, "/api?header=X-A:b%0d%0aSet-Cookie:%20evil=1", nil)
+		if ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+			t.Errorf("Expected 400 for a CRLF in a header value, got %d", ctx.Response.StatusCode())
+		}
+		if got := ctx.Response.Header.PeekCookie("evil"); got != nil {
+			t.Errorf("Expected no injected cookie, got %q", got)
+		}
+		if got := ctx.Response.Header.Peek("X-A"); got != nil {
+			t.Errorf("Expected no X-A header, got %q", got)
+		}
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"status=600", "Invalid status parameter: expected a status code between 100 and 599, got '600'"},
+			{"header=X-Foo", `Invalid header parameter: expected Name:value, got "X-Foo"`},
+			{"header=X%20Foo:bar", `Invalid header parameter: invalid header name "X Foo"`},
+			{"header=X-Foo%0d%0aSet-Cookie:%20evil=1", `Invalid header parameter: invalid header name "X-Foo\r\nSet-Cookie"`},
+			{"header=X-A:b%0d%0aSet-Cookie:%20evil=1", "Invalid header parameter: header value of X-A contains control characters"},
+			{"size=big", "Invalid size parameter: invalid size 'big'"},
+			{"size=2KB", "Invalid size parameter: 2048 bytes exceeds controls.max_size of 1024 bytes"},
+			{"fail_rate=2", "Invalid fail_rate parameter: expected a rate between 0 and 1, got '2'"},
+		}
+		for _, tt := range tests {
+			ctx := request(server, "/api?"+tt.query, nil)
+			if ctx.Response.StatusCode() != 400 || !strings.HasPrefix(string(ctx.Response.Body()), tt.expected) {
+				t.Errorf("%s: expected 400 %q, got %d %q", tt.query, tt.expected, ctx.Response.StatusCode(), ctx.Response.Body())
+			}
+		}
+	})
+
+	t.Run("renamed and moved to headers", func(t *testing.T) {
+		server := newServer(configs.ControlsConfig{
+			Enabled: true,
+			Delay:   configs.ControlSource{Header: "X-Echo-Delay"},
+			Status:  configs.ControlSource{Param: "code", Header: "X-Echo-Status"},
+		})
+
+		if got := request(server, "/api?status=503", nil).Response.StatusCode(); got != 200 {
+			t.Errorf("Expected the renamed status parameter to ignore status, got %d", got)
+		}
+		if got := request(server, "/api?code=502", nil).Response.StatusCode(); got != 502 {
+			t.Errorf("Expected status 502 from the code parameter, got %d", got)
+		}
+		if got := request(server, "/api?code=502", map[string]string{"X-Echo-Status": "504"}).Response.StatusCode(); got != 504 {
+			t.Errorf("Expected the header to win over the parameter, got %d", got)
+		}
+
+		if got := request(server, "/api?delay=invalid", nil).Response.StatusCode(); got != 200 {
+			t.Errorf("Expected the delay parameter to be ignored, got %d", got)
+		}
+		ctx := request(server, "/api", map[string]string{"X-Echo-Delay": "invalid"})
+		if body := string(ctx.Response.Body()); !strings.HasPrefix(body, "Invalid X-Echo-Delay header: ") {
+			t.Errorf("Expected the delay to be read from the header, got %d %q", ctx.Response.StatusCode(), body)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+// sameSiteModes maps same_site values to fasthttp's modes
+var sameSiteModes = map[string]fasthttp.CookieSameSite{
+	configs.SameSiteLax:    fasthttp.CookieSameSiteLaxMode,
+	configs.SameSiteStrict: fasthttp.CookieSameSiteStrictMode,
+	configs.SameSiteNone:   fasthttp.CookieSameSiteNoneMode,
+}
+
+// extractCookies extracts request cookies into a map for condition matching
+func extractCookies(ctx *fasthttp.RequestCtx) map[string]string {
+	cookies := make(map[string]string)
+
+	ctx.Request.Header.VisitAllCookie(func(key, value []byte) {
+		cookies[string(key)] = string(value)
+	})
+
+	return cookies
+}
+
+// setResponseCookies adds a Set-Cookie header for every configured cookie.
+// The headers are added rather than set with SetCookie, which keeps one
+// cookie per name, so cookies that share a name but not a path or domain are
+// all sent.
+func setResponseCookies(ctx *fasthttp.RequestCtx, cookies []configs.Cookie) {
+	now := time.Now()
+	for _, cookie := range cookies {
+		c := fasthttp.AcquireCookie()
+		c.SetKey(cookie.Name)
+		c.SetValue(cookie.Value)
+		if cookie.Path != "" {
+			c.SetPath(cookie.Path)
+		}
+		c.SetDomain(cookie.Domain)
+		c.SetHTTPOnly(cookie.HTTPOnly)
+		c.SetSecure(cookie.Secure)
+		if cookie.MaxAge != 0 {
+			c.SetMaxAge(cookie.MaxAge)
+		}
+		// Expires was validated when the config was loaded
+		if expires, err := cookie.ExpiresAt(now); err == nil && !expires.IsZero() {
+			c.SetExpire(expires)
+		}
+		if mode, ok := sameSiteModes[strings.ToLower(cookie.SameSite)]; ok {
+			c.SetSameSite(mode)
+		}
+
+		ctx.Response.Header.Add(fasthttp.HeaderSetCookie, c.String())
+		fasthttp.ReleaseCookie(c)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+func TestServer_Cookies(t *testing.T) {
+	config := &configs.ServerConfig{
+		Routes: []configs.Route{
+			{
+				Path:         "/login",
+				Method:       "POST",
+				ResponseBody: "logged in",
+				ResponseCookies: []configs.Cookie{
+					{Name: "session", Value: "abc123", Path: "/", HTTPOnly: true, Secure: true, SameSite: "Lax", MaxAge: 3600},
+					{Name: "theme", Value: "dark", Domain: "example.test", Expires: "Wed, 21 Oct 2026 07:28:00 GMT"},
+				},
+			},
+			{
+				Path:           "/profile",
+				Method:         "GET",
+				ResponseStatus: 401,
+				ResponseBody:   "login required",
+				Conditions: []configs.RouteCondition{
+					{
+						CookieMatch:  map[string]string{"session": "abc123"},
+						ResponseBody: "profile",
+					},
+				},
+			},
+			{
+				Path:   "/switch",
+				Method: "POST",
+				ResponseCookies: []configs.Cookie{
+					{Name: "session", Value: "app", Path: "/app"},
+					{Name: "session", Value: "admin", Path: "/admin"},
+				},
+			},
+			{
+				Path:            "/logout",
+				Method:          "POST",
+				ResponseCookies: []configs.Cookie{{Name: "session", Path: "/", MaxAge: -1}},
+			},
+		},
+	}
+	server := &Server{config: config}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+
+	request := func(method, uri string, cookies map[string]string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(uri)
+		ctx.Request.Header.SetMethod(method)
+		for name, value := range cookies {
+			ctx.Request.Header.SetCookie(name, value)
+		}
+		server.Handler(ctx)
+		return ctx
+	}
+	setCookies := func(ctx *fasthttp.RequestCtx) []string {
+		var values []string
+		ctx.Response.Header.VisitAllCookie(func(_, value []byte) {
+			values = append(values, string(value))
+		})
+		return values
+	}
+
+	t.Run("login sets every cookie", func(t *testing.T) {
+		got := setCookies(request("POST", "/login", nil))
+		expected := []string{
+			"session=abc123; max-age=3600; path=/; HttpOnly; secure; SameSite=Lax",
+			"theme=dark; expires=Wed, 21 Oct 2026 07:28:00 GMT; domain=example.test",
+		}
+		if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("Expected Set-Cookie headers\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+		}
+	})
+
+	t.Run("cookies sharing a name", func(t *testing.T) {
+		got := setCookies(request("POST", "/switch", nil))
+		expected := []string{"session=app; path=/app", "session=admin; path=/admin"}
+		if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("Expected Set-Cookie headers\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+		}
+	})
+
+	t.Run("cookie_match", func(t *testing.T) {
+		if got := string(request("GET", "/profile", map[string]string{"session": "abc123"}).Response.Body()); got != "profile" {
+			t.Errorf("Expected profile with session cookie, got %q", got)
+		}
+		ctx := request("GET", "/profile", map[string]string{"session": "expired"})
+		if ctx.Response.StatusCode() != 401 {
+			t.Errorf("Expected 401 with a wrong session cookie, got %d", ctx.Response.StatusCode())
+		}
+	})
+
+	t.Run("logout deletes the cookie", func(t *testing.T) {
+		got := setCookies(request("POST", "/logout", nil))
+		if len(got) != 1 || !strings.Contains(got[0], "max-age=0") {
+			t.Errorf("Expected a deleting Set-Cookie header, got %v", got)
+		}
+	})
+}
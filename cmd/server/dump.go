@@ -0,0 +1,419 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+	"gopkg.in/yaml.v3"
+)
+
+// Body encodings reported in a request dump
+const (
+	bodyEncodingJSON   = "json"
+	bodyEncodingText   = "text"
+	bodyEncodingBase64 = "base64"
+)
+
+// RequestDump represents the structure for request dump data that is included
+// in response bodies when response_dump is enabled in the server configuration.
+// It describes the request as the server received it, which makes the route a
+// debugging echo for clients, proxies and gateways in front of the server.
+type RequestDump struct {
+	RequestID       string                     `json:"request_id,omitempty"`    // ID assigned to the request
+	Method          string                     `json:"method"`                  // Request method
+	URI             string                     `json:"uri"`                     // Raw request URI, including the query string
+	Path            string                     `json:"path"`                    // Decoded request path
+	PathParams      map[string]string          `json:"path_params,omitempty"`   // Values of the route's path parameters
+	Protocol        string                     `json:"protocol"`                // HTTP protocol version
+	Host            string                     `json:"host"`                    // Host the request was sent to
+	RemoteAddr      string                     `json:"remote_addr"`             // Address of the connection's peer
+	ClientIP        string                     `json:"client_ip,omitempty"`     // Client address, resolved through trusted proxies
+	TLS             *TLSDump                   `json:"tls,omitempty"`           // TLS connection details of an HTTPS request
+	ClientCert      *configs.ClientCertificate `json:"client_cert,omitempty"`   // Client certificate of a TLS request
+	Headers         map[string][]string        `json:"headers"`                 // All request headers with every value
+	QueryParameters map[string][]string        `json:"query_parameters"`        // All query parameters with every value
+	Cookies         map[string]string          `json:"cookies,omitempty"`       // Request cookies
+	Body            any                        `json:"body,omitempty"`          // Request body, decoded according to BodyEncoding
+	BodyEncoding    string                     `json:"body_encoding,omitempty"` // How the body is represented: json, text or base64
+	Form            map[string][]string        `json:"form,omitempty"`          // Fields of a URL-encoded or multipart form
+	Files           []FileDump                 `json:"files,omitempty"`         // Files uploaded in a multipart form
+}
+
+// dumpMediaTypes maps the media types of an Accept header to the request dump
+// format they ask for
+var dumpMediaTypes = map[string]string{
+	"application/json":      configs.DumpFormatJSON,
+	"application/yaml":      configs.DumpFormatYAML,
+	"application/x-yaml":    configs.DumpFormatYAML,
+	"text/yaml":             configs.DumpFormatYAML,
+	"text/x-yaml":           configs.DumpFormatYAML,
+	"message/http":          configs.DumpFormatHTTP,
+	"text/plain":            configs.DumpFormatHTTP,
+	"text/html":             configs.DumpFormatHTML,
+	"application/xhtml+xml": configs.DumpFormatHTML,
+}
+
+// dumpContentTypes is the Content-Type of a request dump in each format
+var dumpContentTypes = map[string]string{
+	configs.DumpFormatJSON: "application/json",
+	configs.DumpFormatYAML: "application/yaml",
+	configs.DumpFormatHTTP: "text/plain; charset=utf-8",
+	configs.DumpFormatHTML: "text/html; charset=utf-8",
+}
+
+// TLSDump describes the TLS connection a request was received on
+type TLSDump struct {
+	Version            string `json:"version"`                       // Negotiated TLS version
+	CipherSuite        string `json:"cipher_suite"`                  // Negotiated cipher suite
+	ServerName         string `json:"server_name,omitempty"`         // SNI server name sent by the client
+	NegotiatedProtocol string `json:"negotiated_protocol,omitempty"` // ALPN protocol, if any
+}
+
+// FileDump describes a file uploaded in a multipart form without its contents
+type FileDump struct {
+	Field       string `json:"field"`                  // Form field the file was sent in
+	Filename    string `json:"filename"`               // Name of the file given by the client
+	ContentType string `json:"content_type,omitempty"` // Content type of the file part
+	Size        int64  `json:"size"`                   // Size of the file in bytes
+}
+
+// newRequestDump collects the request data of ctx for a route's response dump
+func (s *Server) newRequestDump(ctx *fasthttp.RequestCtx, route configs.Route, match *configs.MatchContext) RequestDump {
+	info := getRequestInfo(ctx)
+
+	dump := RequestDump{
+		RequestID:       info.requestID,
+		Method:          string(ctx.Method()),
+		URI:             string(ctx.RequestURI()),
+		Path:            string(ctx.Path()),
+		PathParams:      pathParams(ctx, route.Path),
+		Protocol:        string(ctx.Request.Header.Protocol()),
+		Host:            string(ctx.Host()),
+		RemoteAddr:      ctx.RemoteAddr().String(),
+		TLS:             tlsDump(ctx.TLSConnectionState()),
+		ClientCert:      match.ClientCert,
+		Headers:         match.Headers,
+		QueryParameters: s.extractQueryParameters(ctx),
+		Cookies:         match.Cookies,
+	}
+	if info.clientIP.IsValid() {
+		dump.ClientIP = info.clientIP.String()
+	}
+	if len(dump.Cookies) == 0 {
+		dump.Cookies = nil
+	}
+
+	contentType := string(ctx.Request.Header.ContentType())
+	switch {
+	case strings.HasPrefix(contentType, "multipart/form-data"):
+		// The parts are described in Form and Files instead of the raw body
+		form, err := ctx.MultipartForm()
+		if err != nil {
+			dump.Body, dump.BodyEncoding = dumpBody(ctx.Request.Body())
+			break
+		}
+		dump.Form = form.Value
+		for field, headers := range form.File {
+			for _, header := range headers {
+				dump.Files = append(dump.Files, FileDump{
+					Field:       field,
+					Filename:    header.Filename,
+					ContentType: header.Header.Get("Content-Type"),
+					Size:        header.Size,
+				})
+			}
+		}
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		dump.Body, dump.BodyEncoding = dumpBody(ctx.Request.Body())
+		dump.Form = make(map[string][]string)
+		ctx.PostArgs().VisitAll(func(key, value []byte) {
+			dump.Form[string(key)] = append(dump.Form[string(key)], string(value))
+		})
+	default:
+		dump.Body, dump.BodyEncoding = dumpBody(ctx.Request.Body())
+	}
+
+	return dump
+}
+
+// renderRequestDump renders the request in the given format and returns the
+// response body with its Content-Type
+func (s *Server) renderRequestDump(ctx *fasthttp.RequestCtx, route configs.Route, match *configs.MatchContext, format string) ([]byte, string, error) {
+	var body []byte
+	var err error
+
+	switch format {
+	case configs.DumpFormatHTTP:
+		// The request line and headers as fasthttp parsed them, followed by
+		// the body as received
+		body = append(slices.Clone(ctx.Request.Header.Header()), ctx.Request.Body()...)
+	case configs.DumpFormatYAML:
+		body, err = dumpYAML(s.newRequestDump(ctx, route, match))
+	case configs.DumpFormatHTML:
+		var buf bytes.Buffer
+		err = dumpTemplate.Execute(&buf, s.newRequestDump(ctx, route, match))
+		body = buf.Bytes()
+	default:
+		body, err = json.MarshalIndent(s.newRequestDump(ctx, route, match), "", "  ")
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return body, dumpContentTypes[format], nil
+}
+
+// negotiateDumpFormat picks the request dump format from an Accept header,
+// preferring the media type with the highest quality and, among equal
+// qualities, the one listed first. It falls back to JSON.
+func negotiateDumpFormat(accept []byte) string {
+	type candidate struct {
+		format  string
+		quality float64
+	}
+	var candidates []candidate
+
+	for _, mediaRange := range strings.Split(string(accept), ",") {
+		mediaType, params, _ := strings.Cut(mediaRange, ";")
+		format, ok := dumpMediaTypes[strings.ToLower(strings.TrimSpace(mediaType))]
+		if !ok {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			candidates = append(candidates, candidate{format, quality})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return configs.DumpFormatJSON
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].quality > candidates[j].quality })
+	return candidates[0].format
+}
+
+// dumpYAML renders a request dump as YAML. The dump is converted through its
+// JSON form, so the YAML keys, their order and the embedded JSON body match
+// the JSON dump. The JSON is decoded with encoding/json rather than the YAML
+// parser, which rejects some valid JSON such as surrogate pair escapes.
+func dumpYAML(dump RequestDump) ([]byte, error) {
+	data, err := json.Marshal(dump)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	node, err := jsonToYAML(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), encoder.Close()
+}
+
+// jsonToYAML reads the next JSON value from decoder and returns it as a YAML
+// node, keeping the order of object keys
+func jsonToYAML(decoder *json.Decoder) (*yaml.Node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if token == '{' {
+			node.Kind, node.Tag = yaml.MappingNode, "!!map"
+		}
+		for decoder.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+			value, err := jsonToYAML(decoder)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		// Consume the closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: token}, nil
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(token.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: token.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(token)}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
+
+// dumpBody represents a request body as JSON when it parses as JSON, as text
+// when it is valid UTF-8 and as base64 otherwise. An empty body is omitted.
+func dumpBody(body []byte) (any, string) {
+	switch {
+	case len(body) == 0:
+		return nil, ""
+	case json.Valid(body):
+		return json.RawMessage(append([]byte(nil), body...)), bodyEncodingJSON
+	case utf8.Valid(body):
+		return string(body), bodyEncodingText
+	default:
+		return base64.StdEncoding.EncodeToString(body), bodyEncodingBase64
+	}
+}
+
+// tlsDump describes a TLS connection state, or returns nil for plain HTTP
+func tlsDump(state *tls.ConnectionState) *TLSDump {
+	if state == nil {
+		return nil
+	}
+
+	return &TLSDump{
+		Version:            tls.VersionName(state.Version),
+		CipherSuite:        tls.CipherSuiteName(state.CipherSuite),
+		ServerName:         state.ServerName,
+		NegotiatedProtocol: state.NegotiatedProtocol,
+	}
+}
+
+// pathParams returns the values fasthttp/router captured for the parameters
+// of a route path such as /users/{id}, /files/{path:*} or /v{version:[0-9]+}
+func pathParams(ctx *fasthttp.RequestCtx, path string) map[string]string {
+	var params map[string]string
+
+	depth, start := 0, 0
+	for i, c := range path {
+		switch c {
+		case '{':
+			if depth == 0 {
+				start = i + 1
+			}
+			depth++
+		case '}':
+			depth--
+			if depth != 0 {
+				continue
+			}
+			// Drop the optional marker and the regular expression
+			name, _, _ := strings.Cut(path[start:i], ":")
+			name = strings.TrimSuffix(name, "?")
+
+			value, ok := ctx.UserValue(name).(string)
+			if !ok {
+				continue
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[name] = value
+		}
+	}
+
+	return params
+}
+
+// dumpTemplate renders a request dump as a page for browsers
+var dumpTemplate = template.Must(template.New("dump").Funcs(template.FuncMap{
+	"json": func(v any) (string, error) {
+		// The template escapes the output, so the encoder does not have to
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		err := encoder.Encode(v)
+		return strings.TrimSuffix(buf.String(), "\n"), err
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Method}} {{.URI}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+td, pre { font-family: monospace; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>{{.Method}} {{.URI}}</h1>
+<h2>Request</h2>
+<table>
+{{- if .RequestID}}<tr><th>Request ID</th><td>{{.RequestID}}</td></tr>{{end}}
+<tr><th>Path</th><td>{{.Path}}</td></tr>
+<tr><th>Protocol</th><td>{{.Protocol}}</td></tr>
+<tr><th>Host</th><td>{{.Host}}</td></tr>
+<tr><th>Remote address</th><td>{{.RemoteAddr}}</td></tr>
+{{- if .ClientIP}}<tr><th>Client IP</th><td>{{.ClientIP}}</td></tr>{{end}}
+{{- with .TLS}}<tr><th>TLS</th><td>{{.Version}}, {{.CipherSuite}}{{if .ServerName}}, SNI {{.ServerName}}{{end}}{{if .NegotiatedProtocol}}, ALPN {{.NegotiatedProtocol}}{{end}}</td></tr>{{end}}
+{{- with .ClientCert}}<tr><th>Client certificate</th><td>{{.Subject}}{{range .SANs}}<br>{{.}}{{end}}</td></tr>{{end}}
+</table>
+{{- with .PathParams}}
+<h2>Path Parameters</h2>
+<table>{{range $name, $value := .}}<tr><th>{{$name}}</th><td>{{$value}}</td></tr>{{end}}</table>
+{{- end}}
+<h2>Headers</h2>
+<table>{{range $name, $values := .Headers}}{{range $values}}<tr><th>{{$name}}</th><td>{{.}}</td></tr>{{end}}{{end}}</table>
+{{- with .QueryParameters}}
+<h2>Query Parameters</h2>
+<table>{{range $name, $values := .}}{{range $values}}<tr><th>{{$name}}</th><td>{{.}}</td></tr>{{end}}{{end}}</table>
+{{- end}}
+{{- with .Cookies}}
+<h2>Cookies</h2>
+<table>{{range $name, $value := .}}<tr><th>{{$name}}</th><td>{{$value}}</td></tr>{{end}}</table>
+{{- end}}
+{{- with .Form}}
+<h2>Form</h2>
+<table>{{range $name, $values := .}}{{range $values}}<tr><th>{{$name}}</th><td>{{.}}</td></tr>{{end}}{{end}}</table>
+{{- end}}
+{{- with .Files}}
+<h2>Files</h2>
+<table><tr><th>Field</th><th>File name</th><th>Content type</th><th>Size</th></tr>
+{{- range .}}<tr><td>{{.Field}}</td><td>{{.Filename}}</td><td>{{.ContentType}}</td><td>{{.Size}}</td></tr>{{end}}</table>
+{{- end}}
+{{- if .Body}}
+<h2>Body ({{.BodyEncoding}})</h2>
+<pre>{{if eq .BodyEncoding "json"}}{{json .Body}}{{else}}{{.Body}}{{end}}</pre>
+{{- end}}
+</body>
+</html>
+`))
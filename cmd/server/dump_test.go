@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+	"gopkg.in/yaml.v3"
+)
+
+func TestServer_RequestDump(t *testing.T) {
+	config := &configs.ServerConfig{
+		Routes: []configs.Route{
+			{Path: "/users/{id}/files/{name:*}", Method: "POST", ResponseDump: true},
+			{Path: "/dump", Method: "POST", ResponseDump: true},
+		},
+	}
+	server := &Server{config: config}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+
+	request := func(t *testing.T, uri, contentType string, body []byte, setup func(*fasthttp.Request)) RequestDump {
+		t.Helper()
+		var req fasthttp.Request
+		req.Header.SetMethod("POST")
+		req.SetRequestURI(uri)
+		req.Header.SetHost("echo.test")
+		if contentType != "" {
+			req.Header.SetContentType(contentType)
+		}
+		req.SetBody(body)
+		if setup != nil {
+			setup(&req)
+		}
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Init(&req, &net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 4321}, nil)
+		server.Handler(ctx)
+
+		var dump RequestDump
+		if err := json.Unmarshal(ctx.Response.Body(), &dump); err != nil {
+			t.Fatalf("Failed to parse dump %q: %v", ctx.Response.Body(), err)
+		}
+		return dump
+	}
+
+	t.Run("request line and connection", func(t *testing.T) {
+		dump := request(t, "/users/42/files/a/b.txt?tag=x&tag=y", "", nil, func(req *fasthttp.Request) {
+			req.Header.Add("X-Trace", "one")
+			req.Header.Add("X-Trace", "two")
+			req.Header.SetCookie("session", "abc")
+		})
+
+		if dump.Method != "POST" || dump.URI != "/users/42/files/a/b.txt?tag=x&tag=y" || dump.Path != "/users/42/files/a/b.txt" {
+			t.Errorf("Unexpected request line: %s %s (path %s)", dump.Method, dump.URI, dump.Path)
+		}
+		if dump.PathParams["id"] != "42" || dump.PathParams["name"] != "a/b.txt" {
+			t.Errorf("Expected path params id=42 name=a/b.txt, got %v", dump.PathParams)
+		}
+		if dump.Protocol != "HTTP/1.1" || dump.Host != "echo.test" || dump.RemoteAddr != "192.0.2.10:4321" || dump.ClientIP != "192.0.2.10" {
+			t.Errorf("Unexpected connection details: %s %s %s %s", dump.Protocol, dump.Host, dump.RemoteAddr, dump.ClientIP)
+		}
+		if dump.TLS != nil {
+			t.Errorf("Expected no TLS details for plain HTTP, got %+v", dump.TLS)
+		}
+		if !slices.Equal(dump.Headers["X-Trace"], []string{"one", "two"}) {
+			t.Errorf("Expected both X-Trace values, got %v", dump.Headers["X-Trace"])
+		}
+		if !slices.Equal(dump.QueryParameters["tag"], []string{"x", "y"}) {
+			t.Errorf("Expected both tag values, got %v", dump.QueryParameters["tag"])
+		}
+		if dump.Cookies["session"] != "abc" {
+			t.Errorf("Expected session cookie, got %v", dump.Cookies)
+		}
+		if dump.Body != nil || dump.BodyEncoding != "" {
+			t.Errorf("Expected no body, got %v (%s)", dump.Body, dump.BodyEncoding)
+		}
+	})
+
+	t.Run("bodies", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			body     []byte
+			encoding string
+			expected any
+		}{
+			{"json", []byte(`{"name":"alice","tags":["a"]}`), "json", map[string]any{"name": "alice", "tags": []any{"a"}}},
+			{"text", []byte("hello world"), "text", "hello world"},
+			{"binary", []byte{0xff, 0x00, 0xfe}, "base64", "/wD+"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				dump := request(t, "/dump", "application/octet-stream", tt.body, nil)
+				if dump.BodyEncoding != tt.encoding {
+					t.Errorf("Expected body encoding %s, got %s", tt.encoding, dump.BodyEncoding)
+				}
+				got, _ := json.Marshal(dump.Body)
+				expected, _ := json.Marshal(tt.expected)
+				if !bytes.Equal(got, expected) {
+					t.Errorf("Expected body %s, got %s", expected, got)
+				}
+			})
+		}
+	})
+
+	t.Run("urlencoded form", func(t *testing.T) {
+		dump := request(t, "/dump", "application/x-www-form-urlencoded", []byte("color=red&color=blue&size=m"), nil)
+		if !slices.Equal(dump.Form["color"], []string{"red", "blue"}) || !slices.Equal(dump.Form["size"], []string{"m"}) {
+			t.Errorf("Unexpected form fields: %v", dump.Form)
+		}
+		if dump.Body != "color=red&color=blue&size=m" {
+			t.Errorf("Expected the raw form body, got %v", dump.Body)
+		}
+	})
+
+	t.Run("multipart form", func(t *testing.T) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		writer.WriteField("title", "report")
+		part, _ := writer.CreateFormFile("upload", "report.bin")
+		part.Write(bytes.Repeat([]byte{0x01}, 1000))
+		writer.Close()
+
+		dump := request(t, "/dump", writer.FormDataContentType(), body.Bytes(), nil)
+		if !slices.Equal(dump.Form["title"], []string{"report"}) {
+			t.Errorf("Expected title field, got %v", dump.Form)
+		}
+		expected := []FileDump{{Field: "upload", Filename: "report.bin", ContentType: "application/octet-stream", Size: 1000}}
+		if !slices.Equal(dump.Files, expected) {
+			t.Errorf("Expected files %+v, got %+v", expected, dump.Files)
+		}
+		if dump.Body != nil {
+			t.Errorf("Expected the multipart body to be omitted, got %v", dump.Body)
+		}
+	})
+}
+
+func TestNegotiateDumpFormat(t *testing.T) {
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{"", "json"},
+		{"*/*", "json"},
+		{"application/json", "json"},
+		{"application/yaml", "yaml"},
+		{"text/x-yaml", "yaml"},
+		{"message/http", "http"},
+		{"text/plain", "http"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "html"},
+		{"text/html;q=0.5, application/yaml", "yaml"},
+		{"application/yaml;q=0.8, text/plain;q=0.8", "yaml"},
+		{"text/html;q=0, image/png", "json"},
+		{"TEXT/HTML", "html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			if got := negotiateDumpFormat([]byte(tt.accept)); got != tt.expected {
+				t.Errorf("Expected %s for Accept %q, got %s", tt.expected, tt.accept, got)
+			}
+		})
+	}
+}
+
+func TestServer_RequestDumpFormats(t *testing.T) {
+	config := &configs.ServerConfig{
+		Routes: []configs.Route{
+			{Path: "/dump", Method: "POST", ResponseDump: true},
+			{Path: "/dump.yaml", Method: "POST", ResponseDump: true, DumpFormat: "YAML"},
+		},
+	}
+	server := &Server{config: config}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+
+	request := func(uri, accept string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod("POST")
+		ctx.Request.SetRequestURI(uri)
+		ctx.Request.Header.SetHost("echo.test")
+		ctx.Request.Header.Set("Accept", accept)
+		ctx.Request.Header.SetContentType("application/json")
+		ctx.Request.SetBodyString(`{"name":"<alice>"}`)
+		server.Handler(ctx)
+		return ctx
+	}
+
+	t.Run("yaml", func(t *testing.T) {
+		ctx := request("/dump?tag=a&tag=b", "application/yaml")
+		if got := string(ctx.Response.Header.ContentType()); got != "application/yaml" {
+			t.Errorf("Expected application/yaml, got %s", got)
+		}
+		if got := string(ctx.Response.Header.Peek("Vary")); got != "Accept" {
+			t.Errorf("Expected Vary: Accept on a negotiated dump, got %q", got)
+		}
+
+		var dump struct {
+			Method          string              `yaml:"method"`
+			QueryParameters map[string][]string `yaml:"query_parameters"`
+			Body            map[string]string   `yaml:"body"`
+		}
+		if err := yaml.Unmarshal(ctx.Response.Body(), &dump); err != nil {
+			t.Fatalf("Failed to parse YAML dump %q: %v", ctx.Response.Body(), err)
+		}
+		if dump.Method != "POST" || !slices.Equal(dump.QueryParameters["tag"], []string{"a", "b"}) || dump.Body["name"] != "<alice>" {
+			t.Errorf("Unexpected YAML dump: %+v", dump)
+		}
+	})
+
+	t.Run("yaml with surrogate pair escapes", func(t *testing.T) {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod("POST")
+		ctx.Request.SetRequestURI("/dump.yaml")
+		ctx.Request.Header.SetContentType("application/json")
+		ctx.Request.SetBodyString(`{"emoji":"\ud83d\ude00","count":1000000}`)
+		server.Handler(ctx)
+
+		if ctx.Response.StatusCode() != 200 {
+			t.Fatalf("Expected 200, got %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+		}
+		var dump struct {
+			Body map[string]any `yaml:"body"`
+		}
+		if err := yaml.Unmarshal(ctx.Response.Body(), &dump); err != nil {
+			t.Fatalf("Failed to parse YAML dump %q: %v", ctx.Response.Body(), err)
+		}
+		if dump.Body["emoji"] != "\U0001F600" || dump.Body["count"] != 1000000 {
+			t.Errorf("Expected the decoded body in the YAML dump, got %+v", dump.Body)
+		}
+		body := string(ctx.Response.Body())
+		if strings.Index(body, "method:") > strings.Index(body, "headers:") {
+			t.Errorf("Expected the keys in the order of the JSON dump, got %q", body)
+		}
+	})
+
+	t.Run("http", func(t *testing.T) {
+		ctx := request("/dump?tag=a", "text/plain")
+		body := string(ctx.Response.Body())
+		if !strings.HasPrefix(body, "POST /dump?tag=a HTTP/1.1\r\n") || !strings.Contains(body, "Host: echo.test\r\n") {
+			t.Errorf("Expected the request in wire format, got %q", body)
+		}
+		if !strings.HasSuffix(body, "\r\n\r\n"+`{"name":"<alice>"}`) {
+			t.Errorf("Expected the body after the headers, got %q", body)
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		ctx := request("/dump", "text/html,*/*;q=0.8")
+		if got := string(ctx.Response.Header.ContentType()); got != "text/html; charset=utf-8" {
+			t.Errorf("Expected text/html, got %s", got)
+		}
+		body := string(ctx.Response.Body())
+		if !strings.Contains(body, "<h1>POST /dump</h1>") || !strings.Contains(body, "<th>Host</th><td>echo.test</td>") {
+			t.Errorf("Expected an HTML page describing the request, got %q", body)
+		}
+		if strings.Contains(body, "<alice>") || !strings.Contains(body, "&lt;alice&gt;") {
+			t.Errorf("Expected the body to be escaped, got %q", body)
+		}
+	})
+
+	t.Run("route format overrides accept", func(t *testing.T) {
+		ctx := request("/dump.yaml", "text/html")
+		if got := string(ctx.Response.Header.ContentType()); got != "application/yaml" {
+			t.Errorf("Expected application/yaml, got %s", got)
+		}
+		if vary := ctx.Response.Header.Peek("Vary"); vary != nil {
+			t.Errorf("Expected no Vary header with a configured format, got %q", vary)
+		}
+	})
+}
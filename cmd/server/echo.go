@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"unicode/utf8"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+// EchoEnvelope wraps an echoed request body with metadata about the request
+// when response_echo.envelope is set
+type EchoEnvelope struct {
+	RequestID    string `json:"request_id,omitempty"`    // ID assigned to the request
+	Method       string `json:"method"`                  // Request method
+	Path         string `json:"path"`                    // Decoded request path
+	ContentType  string `json:"content_type,omitempty"`  // Content-Type of the request
+	Length       int    `json:"length"`                  // Size of the echoed body in bytes
+	Body         any    `json:"body,omitempty"`          // Echoed body, decoded according to BodyEncoding
+	BodyEncoding string `json:"body_encoding,omitempty"` // How the body is represented: json, text or base64
+}
+
+// echoRequestBody builds the response body of a response_echo route from the
+// request body and returns it with its Content-Type, which is empty when the
+// request had none
+func echoRequestBody(ctx *fasthttp.RequestCtx, echo configs.EchoConfig) ([]byte, string, error) {
+	body := ctx.Request.Body()
+	contentType := string(ctx.Request.Header.ContentType())
+
+	if len(echo.Patch) > 0 {
+		patched, err := configs.ApplyJSONPatch(body, echo.Patch)
+		if err != nil {
+			return nil, "", err
+		}
+		body = patched
+	}
+
+	// Binary bodies are echoed unchanged, uppercasing would corrupt them
+	if echo.Uppercase && utf8.Valid(body) {
+		body = bytes.ToUpper(body)
+	}
+
+	if echo.Envelope {
+		envelope := EchoEnvelope{
+			RequestID:   getRequestInfo(ctx).requestID,
+			Method:      string(ctx.Method()),
+			Path:        string(ctx.Path()),
+			ContentType: contentType,
+			Length:      len(body),
+		}
+		envelope.Body, envelope.BodyEncoding = dumpBody(body)
+
+		data, err := json.MarshalIndent(envelope, "", "  ")
+		if err != nil {
+			return nil, "", err
+		}
+		return data, "application/json", nil
+	}
+
+	return body, contentType, nil
+}
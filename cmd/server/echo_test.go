@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+func TestServer_ResponseEcho(t *testing.T) {
+	config := &configs.ServerConfig{
+		Routes: []configs.Route{
+			{Path: "/echo", Method: "POST", ResponseBody: "ignored", ResponseEcho: configs.EchoConfig{Enabled: true}},
+			{Path: "/shout", Method: "POST", ResponseEcho: configs.EchoConfig{Enabled: true, Uppercase: true}},
+			{
+				Path:           "/envelope",
+				Method:         "POST",
+				ResponseStatus: 201,
+				ResponseEcho:   configs.EchoConfig{Enabled: true, Envelope: true},
+			},
+			{
+				Path:   "/patch",
+				Method: "POST",
+				ResponseEcho: configs.EchoConfig{
+					Enabled: true,
+					Patch: []configs.JSONPatchOperation{
+						{Op: "test", Path: "/version", Value: 1},
+						{Op: "replace", Path: "/version", Value: 2},
+						{Op: "remove", Path: "/password"},
+					},
+				},
+			},
+		},
+	}
+	server := &Server{config: config}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+
+	request := func(uri, contentType, body string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod("POST")
+		ctx.Request.SetRequestURI(uri)
+		if contentType != "" {
+			ctx.Request.Header.SetContentType(contentType)
+		}
+		ctx.Request.SetBodyString(body)
+		server.Handler(ctx)
+		return ctx
+	}
+
+	t.Run("echoes body and content type", func(t *testing.T) {
+		ctx := request("/echo", "application/xml", "<order id=\"1\"/>")
+		if got := string(ctx.Response.Body()); got != "<order id=\"1\"/>" {
+			t.Errorf("Expected the request body, got %q", got)
+		}
+		if got := string(ctx.Response.Header.ContentType()); got != "application/xml" {
+			t.Errorf("Expected the request Content-Type, got %q", got)
+		}
+	})
+
+	t.Run("without content type", func(t *testing.T) {
+		ctx := request("/echo", "", "plain")
+		if got := string(ctx.Response.Header.ContentType()); !strings.HasPrefix(got, "text/plain") {
+			t.Errorf("Expected text/plain, got %q", got)
+		}
+	})
+
+	t.Run("uppercase", func(t *testing.T) {
+		if got := string(request("/shout", "text/plain", "hello, wörld").Response.Body()); got != "HELLO, WÖRLD" {
+			t.Errorf("Expected uppercased body, got %q", got)
+		}
+		if got := request("/shout", "application/octet-stream", "\xff\xfeab").Response.Body(); string(got) != "\xff\xfeab" {
+			t.Errorf("Expected binary body unchanged, got %q", got)
+		}
+	})
+
+	t.Run("envelope", func(t *testing.T) {
+		ctx := request("/envelope", "application/json", `{"name":"alice"}`)
+		if ctx.Response.StatusCode() != 201 {
+			t.Errorf("Expected the configured status 201, got %d", ctx.Response.StatusCode())
+		}
+		if got := string(ctx.Response.Header.ContentType()); got != "application/json" {
+			t.Errorf("Expected application/json, got %q", got)
+		}
+
+		var envelope struct {
+			EchoEnvelope
+			Body map[string]string `json:"body"`
+		}
+		if err := json.Unmarshal(ctx.Response.Body(), &envelope); err != nil {
+			t.Fatalf("Failed to parse envelope %q: %v", ctx.Response.Body(), err)
+		}
+		if envelope.Method != "POST" || envelope.Path != "/envelope" || envelope.ContentType != "application/json" || envelope.Length != 16 {
+			t.Errorf("Unexpected envelope metadata: %+v", envelope.EchoEnvelope)
+		}
+		if envelope.RequestID == "" || envelope.BodyEncoding != "json" || envelope.Body["name"] != "alice" {
+			t.Errorf("Unexpected envelope body: %+v", envelope)
+		}
+	})
+
+	t.Run("json patch", func(t *testing.T) {
+		ctx := request("/patch", "application/json", `{"user":"alice","password":"secret","version":1}`)
+		if got := string(ctx.Response.Body()); got != `{"user":"alice","version":2}` {
+			t.Errorf("Expected the patched body, got %q", got)
+		}
+	})
+
+	t.Run("failed patch", func(t *testing.T) {
+		ctx := request("/patch", "application/json", `{"version":3}`)
+		if ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+			t.Errorf("Expected 400 for a failed test operation, got %d", ctx.Response.StatusCode())
+		}
+		if got := string(ctx.Response.Body()); !strings.Contains(got, "patch[0] (test /version): test failed") {
+			t.Errorf("Expected the failing operation in the error, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+// streamThreshold is the body size above which generated bodies are streamed
+// to the client instead of being built in memory first
+const streamThreshold = 64 << 10
+
+// jsonBufferedCount is the number of generated JSON objects above which the
+// array is streamed
+const jsonBufferedCount = 1000
+
+// fillerLine is repeated by the text type when no text is configured, and by
+// the size control
+const fillerLine = "echo2 generated payload 0123456789 abcdefghijklmnopqrstuvwxyz\n"
+
+// loremWords are the words lorem bodies are made of
+var loremWords = strings.Fields(`lorem ipsum dolor sit amet consectetur adipiscing elit sed do
+	eiusmod tempor incididunt ut labore et dolore magna aliqua enim ad minim veniam quis nostrud
+	exercitation ullamco laboris nisi aliquip ex ea commodo consequat duis aute irure in
+	reprehenderit voluptate velit esse cillum eu fugiat nulla pariatur excepteur sint occaecat
+	cupidatat non proident sunt culpa qui officia deserunt mollit anim id est laborum`)
+
+// alphanumeric are the characters of generated strings
+const alphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// generatedContentTypes is the Content-Type of each generated body type,
+// used when the route does not set one
+var generatedContentTypes = map[string]string{
+	configs.GenerateRandom: "application/octet-stream",
+	configs.GenerateText:   "text/plain; charset=utf-8",
+	configs.GenerateLorem:  "text/plain; charset=utf-8",
+	configs.GenerateJSON:   "application/json",
+}
+
+// writeGenerated sets a generated body on the response. Bodies of a known
+// size are sent with a Content-Length, streamed when larger than
+// streamThreshold; large JSON arrays are streamed with chunked encoding. It
+// returns the body size, or -1 when it is not known in advance.
+func writeGenerated(ctx *fasthttp.RequestCtx, generate configs.GenerateConfig) int64 {
+	// Every response gets its own random source, streams outlive the handler
+	var seed [32]byte
+	for i := 0; i < len(seed); i += 8 {
+		binary.LittleEndian.PutUint64(seed[i:], rand.Uint64())
+	}
+	source := rand.NewChaCha8(seed)
+
+	if generate.Type == configs.GenerateJSON {
+		if generate.Count <= jsonBufferedCount {
+			var buf bytes.Buffer
+			writeJSONArray(&buf, generate, source)
+			ctx.SetBody(buf.Bytes())
+			return int64(buf.Len())
+		}
+		ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			writeJSONArray(w, generate, source)
+		})
+		return -1
+	}
+
+	size := generate.GetSize()
+	reader := io.LimitReader(generatedReader(generate, source), size)
+	if size > streamThreshold {
+		ctx.SetBodyStream(reader, int(size))
+		return size
+	}
+	body, _ := io.ReadAll(reader)
+	ctx.SetBody(body)
+	return size
+}
+
+// generatedReader returns an endless reader of a random, text or lorem body
+func generatedReader(generate configs.GenerateConfig, source *rand.ChaCha8) io.Reader {
+	switch generate.Type {
+	case configs.GenerateRandom:
+		return source
+	case configs.GenerateLorem:
+		return &loremReader{rng: rand.New(source)}
+	}
+
+	text := generate.Text
+	if text == "" {
+		text = fillerLine
+	}
+	return &repeatReader{text: []byte(text)}
+}
+
+// repeatReader repeats text endlessly
+type repeatReader struct {
+	text   []byte
+	offset int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		copied := copy(p[n:], r.text[r.offset:])
+		n += copied
+		r.offset = (r.offset + copied) % len(r.text)
+	}
+	return n, nil
+}
+
+// loremReader produces endless lorem ipsum text, starting with the classic
+// sentence and followed by sentences of random words
+type loremReader struct {
+	rng     *rand.Rand
+	pending []byte
+	started bool
+}
+
+func (r *loremReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.pending) == 0 {
+			r.pending = r.sentence()
+		}
+		copied := copy(p[n:], r.pending)
+		n += copied
+		r.pending = r.pending[copied:]
+	}
+	return n, nil
+}
+
+// sentence returns the next sentence followed by a space
+func (r *loremReader) sentence() []byte {
+	if !r.started {
+		r.started = true
+		return []byte("Lorem ipsum dolor sit amet, consectetur adipiscing elit. ")
+	}
+
+	words := make([]string, 6+r.rng.IntN(10))
+	for i := range words {
+		words[i] = loremWords[r.rng.IntN(len(loremWords))]
+	}
+	words[0] = strings.ToUpper(words[0][:1]) + words[0][1:]
+	return []byte(strings.Join(words, " ") + ". ")
+}
+
+// writeJSONArray writes a JSON array of generated objects with the fields of
+// the schema in name order
+func writeJSONArray(w io.Writer, generate configs.GenerateConfig, source *rand.ChaCha8) {
+	rng := rand.New(source)
+	schema := generate.GetSchema()
+	names := slices.Sorted(maps.Keys(schema))
+	// Field names come from the config and may need escaping
+	keys := make([][]byte, len(names))
+	for i, name := range names {
+		keys[i], _ = json.Marshal(name)
+	}
+
+	now := time.Now()
+	buf := []byte{'['}
+	for index := 1; index <= generate.Count; index++ {
+		if index > 1 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '{')
+		for i, name := range names {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = append(buf, keys[i]...)
+			buf = append(buf, ':')
+			buf = appendFieldValue(buf, schema[name], index, now, source, rng)
+		}
+		buf = append(buf, '}')
+
+		// Flush regularly so large arrays are never held in memory
+		if len(buf) >= 32<<10 {
+			w.Write(buf)
+			buf = buf[:0]
+		}
+	}
+	buf = append(buf, ']')
+	w.Write(buf)
+}
+
+// appendFieldValue appends a random JSON value of a schema field type. The
+// generated strings never need escaping.
+func appendFieldValue(buf []byte, fieldType string, index int, now time.Time, source *rand.ChaCha8, rng *rand.Rand) []byte {
+	switch fieldType {
+	case configs.FieldInt:
+		return strconv.AppendInt(buf, rng.Int64N(1_000_000), 10)
+	case configs.FieldFloat:
+		return strconv.AppendFloat(buf, float64(rng.IntN(100_000))/100, 'f', 2, 64)
+	case configs.FieldBool:
+		return strconv.AppendBool(buf, rng.IntN(2) == 1)
+	case configs.FieldIndex:
+		return strconv.AppendInt(buf, int64(index), 10)
+	case configs.FieldUUID:
+		// Reading from a ChaCha8 source never fails
+		id, _ := uuid.NewRandomFromReader(source)
+		buf = append(buf, '"')
+		buf = append(buf, id.String()...)
+		return append(buf, '"')
+	case configs.FieldTime:
+		t := now.Add(-time.Duration(rng.Int64N(int64(365 * 24 * time.Hour)))).UTC().Truncate(time.Second)
+		buf = append(buf, '"')
+		buf = t.AppendFormat(buf, time.RFC3339)
+		return append(buf, '"')
+	}
+
+	buf = append(buf, '"')
+	for range 8 + rng.IntN(9) {
+		buf = append(buf, alphanumeric[rng.IntN(len(alphanumeric))])
+	}
+	return append(buf, '"')
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+func TestServer_ResponseGenerate(t *testing.T) {
+	routes := []configs.Route{
+		{Path: "/random", ResponseGenerate: configs.GenerateConfig{Type: configs.GenerateRandom, Size: "1KB"}},
+		{Path: "/text", ResponseGenerate: configs.GenerateConfig{Type: configs.GenerateText, Size: "10", Text: "abc"}},
+		{Path: "/lorem", ResponseGenerate: configs.GenerateConfig{Type: configs.GenerateLorem, Size: "2KB"}},
+		{Path: "/large", ResponseGenerate: configs.GenerateConfig{Type: configs.GenerateText, Size: "1MB"}},
+		{
+			Path:             "/json",
+			ResponseGenerate: configs.GenerateConfig{Type: configs.GenerateJSON, Count: 3, Schema: map[string]string{"id": "index", "uid": "uuid", "price": "float", "active": "bool", "created": "time", "qty": "int", "name": "string"}},
+		},
+		{Path: "/stream", ResponseGenerate: configs.GenerateConfig{Type: configs.GenerateJSON, Count: 5000}},
+		{
+			Path:             "/typed",
+			ResponseHeader:   map[string]configs.HeaderValues{"content-type": {"application/x-custom"}},
+			ResponseGenerate: configs.GenerateConfig{Type: configs.GenerateRandom, Size: "16"},
+		},
+	}
+	server := &Server{config: &configs.ServerConfig{Routes: routes}}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+
+	request := func(uri string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(uri)
+		server.Handler(ctx)
+		return ctx
+	}
+
+	tests := []struct {
+		path        string
+		size        int
+		contentType string
+	}{
+		{"/random", 1024, "application/octet-stream"},
+		{"/text", 10, "text/plain; charset=utf-8"},
+		{"/lorem", 2048, "text/plain; charset=utf-8"},
+		{"/typed", 16, "application/x-custom"},
+	}
+	for _, tt := range tests {
+		ctx := request(tt.path)
+		if got := len(ctx.Response.Body()); got != tt.size {
+			t.Errorf("%s: expected %d bytes, got %d", tt.path, tt.size, got)
+		}
+		if got := string(ctx.Response.Header.ContentType()); got != tt.contentType {
+			t.Errorf("%s: expected Content-Type %q, got %q", tt.path, tt.contentType, got)
+		}
+	}
+
+	t.Run("text", func(t *testing.T) {
+		if got := string(request("/text").Response.Body()); got != "abcabcabca" {
+			t.Errorf("Expected repeated text, got %q", got)
+		}
+	})
+
+	t.Run("lorem", func(t *testing.T) {
+		body := request("/lorem").Response.Body()
+		if !strings.HasPrefix(string(body), "Lorem ipsum dolor sit amet") || !utf8.Valid(body) {
+			t.Errorf("Expected lorem ipsum text, got %q", body[:40])
+		}
+	})
+
+	t.Run("random bodies differ", func(t *testing.T) {
+		if string(request("/random").Response.Body()) == string(request("/random").Response.Body()) {
+			t.Error("Expected two random bodies to differ")
+		}
+	})
+
+	t.Run("large bodies are streamed", func(t *testing.T) {
+		ctx := request("/large")
+		if !ctx.Response.IsBodyStream() {
+			t.Error("Expected a 1MB body to be streamed")
+		}
+		if got := ctx.Response.Header.ContentLength(); got != 1<<20 {
+			t.Errorf("Expected Content-Length %d, got %d", 1<<20, got)
+		}
+		if got := len(ctx.Response.Body()); got != 1<<20 {
+			t.Errorf("Expected %d bytes, got %d", 1<<20, got)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		ctx := request("/json")
+		if got := string(ctx.Response.Header.ContentType()); got != "application/json" {
+			t.Errorf("Expected application/json, got %q", got)
+		}
+
+		var objects []map[string]any
+		if err := json.Unmarshal(ctx.Response.Body(), &objects); err != nil {
+			t.Fatalf("Expected a JSON array, got %v: %s", err, ctx.Response.Body())
+		}
+		if len(objects) != 3 {
+			t.Fatalf("Expected 3 objects, got %d", len(objects))
+		}
+
+		uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+		for i, object := range objects {
+			if object["id"] != float64(i+1) {
+				t.Errorf("object %d: expected id %d, got %v", i, i+1, object["id"])
+			}
+			if uid, _ := object["uid"].(string); !uuidPattern.MatchString(uid) {
+				t.Errorf("object %d: expected a version 4 UUID, got %v", i, object["uid"])
+			}
+			if _, ok := object["price"].(float64); !ok {
+				t.Errorf("object %d: expected a numeric price, got %v", i, object["price"])
+			}
+			if _, ok := object["active"].(bool); !ok {
+				t.Errorf("object %d: expected a boolean active, got %v", i, object["active"])
+			}
+			if name, _ := object["name"].(string); name == "" {
+				t.Errorf("object %d: expected a string name, got %v", i, object["name"])
+			}
+			if created, _ := object["created"].(string); !regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`).MatchString(created) {
+				t.Errorf("object %d: expected an RFC 3339 time, got %v", i, object["created"])
+			}
+		}
+	})
+
+	t.Run("large json arrays are streamed", func(t *testing.T) {
+		ln := fasthttputil.NewInmemoryListener()
+		defer ln.Close()
+		go fasthttp.Serve(ln, server.Handler)
+
+		client := &http.Client{Transport: &http.Transport{
+			Dial: func(network, addr string) (net.Conn, error) { return ln.Dial() },
+		}}
+		resp, err := client.Get("http://localhost/stream")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if len(resp.TransferEncoding) == 0 || resp.TransferEncoding[0] != "chunked" {
+			t.Errorf("Expected a chunked response, got %v", resp.TransferEncoding)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		var objects []map[string]any
+		if err := json.Unmarshal(body, &objects); err != nil {
+			t.Fatalf("Expected a JSON array, got %v", err)
+		}
+		if len(objects) != 5000 || objects[4999]["id"] != float64(5000) {
+			t.Errorf("Expected 5000 objects with default schema, got %d", len(objects))
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket
+// activation. It is a variable so tests can pass their own descriptors.
+var listenFDsStart = 3
+
+// activatedFiles caches the sockets inherited through LISTEN_FDS. The
+// environment is only read once because each socket can only be taken once.
+var activatedFiles = sync.OnceValues(inheritedFiles)
+
+// activatedFile is a socket passed by the service manager with its name from
+// LISTEN_FDNAMES
+type activatedFile struct {
+	name  string
+	file  *os.File
+	taken bool
+}
+
+// listen opens the net.Listener for address: a unix domain socket for
+// unix:/path.sock, a socket inherited from systemd for systemd: or
+// systemd:<name>, and a TCP socket otherwise
+func listen(address string) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(address, configs.UnixAddressPrefix):
+		return listenUnix(strings.TrimPrefix(address, configs.UnixAddressPrefix))
+	case strings.HasPrefix(address, configs.SystemdAddressPrefix):
+		return listenActivated(strings.TrimPrefix(address, configs.SystemdAddressPrefix))
+	}
+	return net.Listen("tcp4", address)
+}
+
+// listenUnix listens on a unix domain socket at path, replacing the socket
+// file left behind by a previous run that did not exit cleanly
+func listenUnix(path string) (net.Listener, error) {
+	info, err := os.Lstat(path)
+	switch {
+	case err == nil && info.Mode().Type() != fs.ModeSocket:
+		return nil, fmt.Errorf("%s exists and is not a socket", path)
+	case err == nil:
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// Remove the socket file when the listener is closed on shutdown
+	listener.(*net.UnixListener).SetUnlinkOnClose(true)
+	return listener, nil
+}
+
+// listenActivated returns the inherited socket with the given name. An empty
+// name selects the only inherited socket.
+func listenActivated(name string) (net.Listener, error) {
+	files, err := activatedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var selected *activatedFile
+	switch {
+	case name == "" && len(files) == 1:
+		selected = files[0]
+	case name == "":
+		return nil, fmt.Errorf("%d sockets were passed, select one with systemd:<name>", len(files))
+	default:
+		for _, f := range files {
+			if f.name == name {
+				selected = f
+				break
+			}
+		}
+		if selected == nil {
+			return nil, fmt.Errorf("no socket named '%s' was passed in LISTEN_FDNAMES", name)
+		}
+	}
+
+	if selected.taken {
+		return nil, fmt.Errorf("socket '%s' is already used by another listener", selected.name)
+	}
+	selected.taken = true
+
+	// FileListener duplicates the descriptor, so the inherited one is closed
+	defer selected.file.Close()
+	return net.FileListener(selected.file)
+}
+
+// inheritedFiles reads the sockets passed through the LISTEN_PID, LISTEN_FDS
+// and LISTEN_FDNAMES environment variables of systemd socket activation
+func inheritedFiles() ([]*activatedFile, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, errors.New("no sockets were passed by socket activation (LISTEN_PID is not set to this process)")
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS '%s'", os.Getenv("LISTEN_FDS"))
+	}
+
+	var names []string
+	if value := os.Getenv("LISTEN_FDNAMES"); value != "" {
+		names = strings.Split(value, ":")
+	}
+
+	files := make([]*activatedFile, count)
+	for i := range count {
+		// systemd names unnamed sockets "unknown"
+		name := "unknown"
+		if i < len(names) {
+			name = names[i]
+		}
+		fd := listenFDsStart + i
+		files[i] = &activatedFile{name: name, file: os.NewFile(uintptr(fd), name)}
+	}
+	return files, nil
+}
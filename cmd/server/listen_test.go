@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+func TestListen_Unix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "echo2.sock")
+
+	// A socket left behind by a previous run is replaced
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to create stale socket: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, err := listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("Expected stale socket to be replaced, got %v", err)
+	}
+
+	server := &Server{config: &configs.ServerConfig{
+		Routes: []configs.Route{{Path: "/hello", Method: "GET", ResponseBody: "over unix"}},
+	}}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+	httpServer, err := newHTTPServer(newReloadableHandler(server), configs.Listener{Address: "unix:" + path})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	go serveListener(httpServer, ln)
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://echo2/hello")
+	if err != nil {
+		t.Fatalf("Request over unix socket failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "over unix" {
+		t.Errorf("Expected body 'over unix', got %q", body)
+	}
+
+	// The socket file is removed on shutdown
+	if err := httpServer.Shutdown(); err != nil {
+		t.Fatalf("Failed to shut down: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected socket file to be removed on shutdown, got %v", err)
+	}
+
+	t.Run("regular file is not replaced", func(t *testing.T) {
+		file := filepath.Join(dir, "config.yaml")
+		if err := os.WriteFile(file, []byte("routes: []"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := listen("unix:" + file); err == nil || !strings.Contains(err.Error(), "not a socket") {
+			t.Errorf("Expected 'not a socket' error, got %v", err)
+		}
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("Expected regular file to be kept, got %v", err)
+		}
+	})
+}
+
+func TestListen_Systemd(t *testing.T) {
+	// Pass sockets the way systemd would, through consecutive descriptors and
+	// the LISTEN_* environment
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer tcp.Close()
+	file, err := tcp.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("Failed to get descriptor: %v", err)
+	}
+	defer file.Close()
+
+	originalStart, originalFiles := listenFDsStart, activatedFiles
+	defer func() { listenFDsStart, activatedFiles = originalStart, originalFiles }()
+	activate := func(t *testing.T, pid string, count int, names string) {
+		t.Helper()
+		fds := make([]int, count)
+		for i := range fds {
+			fd, err := syscall.Dup(int(file.Fd()))
+			if err != nil {
+				t.Fatalf("Failed to duplicate descriptor: %v", err)
+			}
+			fds[i] = fd
+			if i > 0 && fd != fds[0]+i {
+				t.Skip("Descriptors are not consecutive")
+			}
+		}
+		t.Setenv("LISTEN_PID", pid)
+		t.Setenv("LISTEN_FDS", strconv.Itoa(count))
+		t.Setenv("LISTEN_FDNAMES", names)
+		listenFDsStart = fds[0]
+		activatedFiles = sync.OnceValues(inheritedFiles)
+	}
+	pid := strconv.Itoa(os.Getpid())
+
+	t.Run("not activated", func(t *testing.T) {
+		t.Setenv("LISTEN_PID", "1")
+		t.Setenv("LISTEN_FDS", "1")
+		activatedFiles = sync.OnceValues(inheritedFiles)
+		if _, err := listen("systemd:"); err == nil || !strings.Contains(err.Error(), "LISTEN_PID") {
+			t.Errorf("Expected LISTEN_PID error, got %v", err)
+		}
+	})
+
+	t.Run("select by name", func(t *testing.T) {
+		activate(t, pid, 2, "http")
+		if _, err := listen("systemd:"); err == nil || !strings.Contains(err.Error(), "2 sockets were passed") {
+			t.Errorf("Expected ambiguous socket error, got %v", err)
+		}
+		if _, err := listen("systemd:admin"); err == nil || !strings.Contains(err.Error(), "no socket named 'admin'") {
+			t.Errorf("Expected unknown name error, got %v", err)
+		}
+		// Sockets missing from LISTEN_FDNAMES are named "unknown"
+		ln, err := listen("systemd:unknown")
+		if err != nil {
+			t.Fatalf("Expected the unnamed socket, got %v", err)
+		}
+		ln.Close()
+	})
+
+	t.Run("serve inherited socket", func(t *testing.T) {
+		activate(t, pid, 1, "")
+		ln, err := listen("systemd:")
+		if err != nil {
+			t.Fatalf("Expected inherited socket, got %v", err)
+		}
+
+		server := &Server{config: &configs.ServerConfig{
+			Routes: []configs.Route{{Path: "/hello", Method: "GET", ResponseBody: "activated"}},
+		}}
+		if err := server.initializeRouter(); err != nil {
+			t.Fatalf("initializeRouter() error = %v", err)
+		}
+		httpServer := &fasthttp.Server{Handler: server.Handler}
+		go serveListener(httpServer, ln)
+		defer httpServer.Shutdown()
+
+		resp, err := http.Get("http://" + tcp.Addr().String() + "/hello")
+		if err != nil {
+			t.Fatalf("Request to inherited socket failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "activated" {
+			t.Errorf("Expected body 'activated', got %q", body)
+		}
+
+		if _, err := listen("systemd:"); err == nil || !strings.Contains(err.Error(), "already used") {
+			t.Errorf("Expected a socket to be used once, got %v", err)
+		}
+	})
+}
@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"flag"
+	"fmt"
+	"io"
 	"log/slog"
+	"net"
+	"net/netip"
 	"os"
 	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
@@ -23,8 +26,8 @@ var logger *slog.Logger
 // Global shutdown channel to signal when server is shutting down
 var shutdownChan = make(chan struct{})
 
-// setupLogger configures the slog logger with the specified level
-func setupLogger(level string) {
+// setupLogger configures the slog logger with the specified level and format
+func setupLogger(level, format string) {
 	var logLevel slog.Level
 	switch strings.ToLower(level) {
 	case "debug":
@@ -42,48 +45,119 @@ func setupLogger(level string) {
 	opts := &slog.HandlerOptions{
 		Level: logLevel,
 	}
-	handler := slog.NewTextHandler(os.Stdout, opts)
-	logger = slog.New(handler)
+	logger = slog.New(newLogHandler(os.Stdout, format, opts))
 	slog.SetDefault(logger)
 }
 
+// newLogHandler creates a JSON or text slog handler writing to w. Records
+// logged with a request's context carry its request ID.
+func newLogHandler(w io.Writer, format string, opts *slog.HandlerOptions) slog.Handler {
+	if strings.EqualFold(format, configs.LogFormatJSON) {
+		return requestIDHandler{slog.NewJSONHandler(w, opts)}
+	}
+	return requestIDHandler{slog.NewTextHandler(w, opts)}
+}
+
 func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// runServe loads the configuration and serves the configured routes until
+// SIGINT or SIGTERM is received. It returns the process exit code.
+func runServe(args []string, stderr io.Writer) int {
 	// Parse command line flags
-	configPath := flag.String("config", "config.yaml", "Path to configuration file")
-	flag.Parse()
+	flags, paths := newConfigFlagSet("serve", stderr)
+	refreshInterval := flags.Duration("config-refresh", 0, "Reload the configuration at this interval (e.g. 30s), 0 disables")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	configPaths := paths.orDefault()
+	if *refreshInterval > 0 && slices.Contains(configPaths, configs.StdinPath) {
+		fmt.Fprintln(stderr, "-config-refresh cannot be used with a configuration read from stdin")
+		return 2
+	}
 
 	// Load configuration
-	config, err := configs.LoadConfig(*configPath)
+	config, err := configs.LoadConfigs(configPaths...)
 	if err != nil {
 		slog.Error("Failed to load config", "error", err)
-		os.Exit(1)
+		return 1
 	}
 
-	// Setup logger with configured level
-	setupLogger(config.GetLogLevel())
+	// Setup logger with configured level and format
+	setupLogger(config.GetLogLevel(), config.GetLogFormat())
 
-	slog.Info("Starting server", "address", config.Address)
 	slog.Info("Loaded routes", "count", len(config.Routes))
 
-	// Create the server
+	// The metrics, access log and tracing are shared by every listener
 	appServer := &Server{config: config}
+	if config.Metrics.Enabled {
+		appServer.metrics = newMetrics()
+	}
+	if config.AccessLog.Enabled {
+		appServer.accessLog, err = newAccessLogger(config.AccessLog, config.GetLogFormat())
+		if err != nil {
+			slog.Error("Failed to open access log", "error", err)
+			return 1
+		}
+		defer appServer.accessLog.Close()
+	}
+	if config.Tracing.Enabled {
+		exporter, err := newOTLPExporter(config.Tracing)
+		if err != nil {
+			slog.Error("Failed to create trace exporter", "error", err)
+			return 1
+		}
+		appServer.tracing = newTracing(config.Tracing, exporter)
+		slog.Info("Exporting traces", "endpoint", config.Tracing.Endpoint)
+	}
 
-	// Initialize router with configured routes
-	appServer.initializeRouter()
+	// Create a server with its own router for every listener, served through
+	// handlers that let a refreshed configuration replace the routers
+	var handlers []*reloadableHandler
+	var httpServers []*fasthttp.Server
+	for _, listener := range config.GetListeners() {
+		server := appServer.forListener(config, listener.Name)
+		if err := server.initializeRouter(); err != nil {
+			slog.Error("Failed to initialize routes", "listener", listener.Name, "error", err)
+			return 1
+		}
+		handler := newReloadableHandler(server)
+		handlers = append(handlers, handler)
 
-	// Create fasthttp server with router handler
-	httpServer := &fasthttp.Server{
-		Handler: appServer.router.Handler,
-		Name:    "echo-server",
-	}
+		httpServer, err := newHTTPServer(handler, listener)
+		if err != nil {
+			slog.Error("Failed to configure listener", "listener", listener.Name, "error", err)
+			return 1
+		}
+		httpServers = append(httpServers, httpServer)
 
-	// Start server in a goroutine
-	go func() {
-		if err := httpServer.ListenAndServe(config.Address); err != nil {
-			slog.Error("Error starting server", "error", err)
-			os.Exit(1)
+		// Open the socket before serving so a bad address fails the start
+		ln, err := listen(listener.Address)
+		if err != nil {
+			slog.Error("Failed to listen", "address", listener.Address, "listener", listener.Name, "error", err)
+			return 1
 		}
-	}()
+		if listener.ProxyProtocol {
+			ln = &proxyListener{ln}
+		}
+
+		slog.Info("Starting server", "address", listener.Address, "listener", listener.Name,
+			"tls", httpServer.TLSConfig != nil, "proxy_protocol", listener.ProxyProtocol)
+
+		// Start server in a goroutine
+		go func() {
+			if err := serveListener(httpServer, ln); err != nil {
+				slog.Error("Error starting server", "address", listener.Address, "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	if *refreshInterval > 0 {
+		slog.Info("Refreshing config periodically", "interval", refreshInterval.String())
+		go refreshConfig(handlers, configPaths, *refreshInterval)
+	}
 
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -99,29 +173,115 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Attempt graceful shutdown
-	if err := httpServer.ShutdownWithContext(ctx); err != nil {
-		slog.Error("Server forced to shutdown", "error", err)
-	} else {
+	// Attempt graceful shutdown of every listener
+	graceful := true
+	for _, httpServer := range httpServers {
+		if err := httpServer.ShutdownWithContext(ctx); err != nil {
+			slog.Error("Server forced to shutdown", "error", err)
+			graceful = false
+		}
+	}
+	if graceful {
 		slog.Info("Server exited gracefully")
 	}
+
+	// Flush spans of the last requests
+	if appServer.tracing != nil {
+		if err := appServer.tracing.shutdown(ctx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}
+
+	return 0
+}
+
+// newHTTPServer creates the fasthttp server for a listener, serving HTTPS
+// when the listener has a certificate configured or generated
+func newHTTPServer(handler *reloadableHandler, listener configs.Listener) (*fasthttp.Server, error) {
+	httpServer := &fasthttp.Server{
+		Handler: handler.Handler,
+		Name:    "echo-server",
+	}
+
+	if listener.TLS.IsEnabled() {
+		tlsConfig, err := newTLSConfig(listener.TLS)
+		if err != nil {
+			return nil, err
+		}
+		httpServer.TLSConfig = tlsConfig
+		if listener.TLS.SelfSigned {
+			slog.Info("Generated self-signed certificate", "listener", listener.Name,
+				"sha256", certificateFingerprint(tlsConfig.Certificates[0]))
+		}
+	}
+
+	return httpServer, nil
+}
+
+// serveListener accepts connections on ln until the server shuts down
+func serveListener(httpServer *fasthttp.Server, ln net.Listener) error {
+	if httpServer.TLSConfig != nil {
+		// The certificate is already in TLSConfig, so no files are passed
+		return httpServer.ServeTLS(ln, "", "")
+	}
+	return httpServer.Serve(ln)
 }
 
 // Server holds the server configuration and handles requests.
 // The server uses fasthttp/router for efficient HTTP routing instead of manual path matching.
 // This provides better performance and proper HTTP status code handling.
 type Server struct {
-	config *configs.ServerConfig // Server configuration loaded from YAML
-	router *router.Router        // FastHTTP router for efficient request routing
+	config         *configs.ServerConfig // Server configuration loaded from YAML
+	listener       string                // Name of the listener the routes are served on, empty without listeners
+	router         *router.Router        // FastHTTP router for efficient request routing, for routes without a host
+	hostRouters    []hostRouter          // Routers of host-scoped routes, in matching order
+	trustedProxies []netip.Prefix        // Peers whose forwarding headers are believed
+	metrics        *metrics              // Prometheus collectors, nil when not collecting
+	tracing        *tracing              // OpenTelemetry tracing, nil when disabled
+	accessLog      *accessLogger         // Access log, nil when disabled
+}
+
+// hostRouter serves the routes scoped to a host pattern
+type hostRouter struct {
+	pattern string // Lowercased host pattern, e.g. api.example.test or *.partner.test
+	router  *router.Router
 }
 
-// RequestDump represents the structure for request dump data that is included
-// in response bodies when response_dump is enabled in the server configuration.
-// This is useful for debugging and understanding what headers and query parameters
-// the server receives from clients.
-type RequestDump struct {
-	Headers         map[string]string `json:"headers"`          // All request headers as key-value pairs
-	QueryParameters map[string]string `json:"query_parameters"` // All query parameters as key-value pairs
+// forListener returns a server for the named listener that uses config and
+// shares s's metrics, access log and tracing
+func (s *Server) forListener(config *configs.ServerConfig, listener string) *Server {
+	return &Server{
+		config:    config,
+		listener:  listener,
+		metrics:   s.metrics,
+		tracing:   s.tracing,
+		accessLog: s.accessLog,
+	}
+}
+
+// requestInfo records what happened while a route handled a request, so it can
+// be reported once the response has been written
+type requestInfo struct {
+	route     string        // Path pattern of the matched route, empty when no route matched
+	condition int           // Index of the matched condition, -1 for the route's default response
+	delay     time.Duration // Delay requested with the delay parameter
+	cancelled bool          // Whether the delay was cut short by server shutdown
+	requestID string        // ID from the request ID header, or generated
+	clientIP  netip.Addr    // Client address, resolved through trusted proxies
+}
+
+// requestInfoKey is the RequestCtx user value holding the request's *requestInfo
+const requestInfoKey = "echo2.requestInfo"
+
+// getRequestInfo returns the requestInfo attached to ctx, attaching a new one
+// on first use
+func getRequestInfo(ctx *fasthttp.RequestCtx) *requestInfo {
+	if info, ok := ctx.UserValue(requestInfoKey).(*requestInfo); ok {
+		return info
+	}
+	info := &requestInfo{condition: -1}
+	ctx.SetUserValue(requestInfoKey, info)
+	return info
 }
 
 // initializeRouter sets up the fasthttp/router with all configured routes.
@@ -131,11 +291,25 @@ type RequestDump struct {
 // - Proper HTTP status codes (405 for wrong methods, 404 for missing paths)
 // - Support for all HTTP methods including custom ones
 // - Better performance for high-traffic scenarios
-func (s *Server) initializeRouter() {
+//
+// The router panics on routes it cannot register; the panic is returned as an
+// error so that a refreshed config cannot stop the server.
+func (s *Server) initializeRouter() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to register routes: %v", r)
+		}
+	}()
+
 	s.router = router.New()
+	s.hostRouters = nil
+	s.trustedProxies = parseTrustedProxies(s.config.TrustedProxies)
 
-	// Add all configured routes to the router
+	// Add the configured routes served on this server's listener
 	for _, route := range s.config.Routes {
+		if !route.ServedOn(s.listener) {
+			continue
+		}
 		method := strings.ToUpper(route.GetMethod())
 		path := route.Path
 
@@ -145,29 +319,41 @@ func (s *Server) initializeRouter() {
 			s.handleRouteRequest(ctx, routeConfig)
 		}
 
+		// Routes scoped to a host get that host's router
+		r := s.router
+		if route.Host != "" {
+			r = s.routerForHost(route.Host)
+		}
+
 		// Register the route with the appropriate HTTP method
 		switch method {
 		case "GET":
-			s.router.GET(path, handler)
+			r.GET(path, handler)
 		case "POST":
-			s.router.POST(path, handler)
+			r.POST(path, handler)
 		case "PUT":
-			s.router.PUT(path, handler)
+			r.PUT(path, handler)
 		case "DELETE":
-			s.router.DELETE(path, handler)
+			r.DELETE(path, handler)
 		case "PATCH":
-			s.router.PATCH(path, handler)
+			r.PATCH(path, handler)
 		case "HEAD":
-			s.router.HEAD(path, handler)
+			r.HEAD(path, handler)
 		case "OPTIONS":
-			s.router.OPTIONS(path, handler)
+			r.OPTIONS(path, handler)
 		default:
 			// For any other methods, use the ANY method (supports all HTTP methods)
 			slog.Warn("Unknown HTTP method, registering as ANY", "method", method, "path", path)
-			s.router.ANY(path, handler)
+			r.ANY(path, handler)
 		}
 
-		slog.Debug("Registered route", "method", method, "path", path)
+		slog.Debug("Registered route", "method", method, "path", path, "host", route.Host)
+	}
+
+	// Expose Prometheus metrics when enabled
+	if s.metrics != nil && s.config.Metrics.Enabled {
+		s.router.GET(s.config.Metrics.Path, s.metrics.handler)
+		slog.Debug("Registered metrics endpoint", "path", s.config.Metrics.Path)
 	}
 
 	// Add a catch-all route for 404 handling
@@ -176,33 +362,117 @@ func (s *Server) initializeRouter() {
 		ctx.SetContentType("text/plain")
 		ctx.WriteString("404 Not Found")
 	}
+
+	// Requests a host's routes do not handle fall through to the routes for
+	// any host. Exact hosts are tried before wildcards, longer wildcards first.
+	for _, hr := range s.hostRouters {
+		hr.router.NotFound = s.router.Handler
+	}
+	slices.SortStableFunc(s.hostRouters, func(a, b hostRouter) int {
+		aWildcard, bWildcard := strings.HasPrefix(a.pattern, "*"), strings.HasPrefix(b.pattern, "*")
+		if aWildcard != bWildcard {
+			if aWildcard {
+				return 1
+			}
+			return -1
+		}
+		return len(b.pattern) - len(a.pattern)
+	})
+	return nil
+}
+
+// routerForHost returns the router of a host pattern, creating it on first use
+func (s *Server) routerForHost(pattern string) *router.Router {
+	pattern = strings.ToLower(pattern)
+	for _, hr := range s.hostRouters {
+		if hr.pattern == pattern {
+			return hr.router
+		}
+	}
+
+	r := router.New()
+	// Let a method the host does not handle fall through to the routes for
+	// any host instead of answering 405 right away
+	r.HandleMethodNotAllowed = false
+	s.hostRouters = append(s.hostRouters, hostRouter{pattern: pattern, router: r})
+	return r
+}
+
+// Handler serves a request with the router of the host it was sent to, or
+// with the routes for any host when no host pattern matches. Every request,
+// including those answered with 404 or 405 and the metrics endpoint, gets a
+// request ID and an access log entry.
+func (s *Server) Handler(ctx *fasthttp.RequestCtx) {
+	assignRequestID(ctx, s.config.RequestID.GetHeader())
+	getRequestInfo(ctx).clientIP = resolveClientIP(ctx, s.trustedProxies)
+
+	// Access log at debug level
+	slog.DebugContext(ctx, "Received request", "method", string(ctx.Method()), "path", string(ctx.Path()))
+	start := time.Now()
+
+	s.dispatch(ctx)
+
+	if s.accessLog != nil {
+		s.accessLog.log(ctx, getRequestInfo(ctx), time.Since(start))
+	}
+}
+
+// dispatch passes a request to the router of its host
+func (s *Server) dispatch(ctx *fasthttp.RequestCtx) {
+	if len(s.hostRouters) > 0 {
+		host := string(ctx.Host())
+		for _, hr := range s.hostRouters {
+			if configs.MatchHost(hr.pattern, host) {
+				hr.router.Handler(ctx)
+				return
+			}
+		}
+	}
+	s.router.Handler(ctx)
 }
 
 // handleRouteRequest processes a specific route request (used by router).
 // This method is called by the fasthttp/router when a route matches an incoming request.
 // It serves as an adapter between the router and the existing route processing logic,
-// providing access logging and delegating actual response handling to handleRoute.
+// providing metrics and tracing and delegating actual response handling to handleRoute.
 //
 // Parameters:
 // - ctx: The fasthttp request context containing request/response data
 // - route: The matched route configuration with response details
 func (s *Server) handleRouteRequest(ctx *fasthttp.RequestCtx, route configs.Route) {
-	path := string(ctx.Path())
-	method := string(ctx.Method())
+	getRequestInfo(ctx).route = route.Path
 
-	// Access log at debug level
-	slog.Debug("Received request", "method", method, "path", path)
+	if s.metrics != nil {
+		s.metrics.inFlight.Inc()
+		defer s.metrics.inFlight.Dec()
+	}
+	if s.tracing != nil {
+		span := s.tracing.start(ctx, route)
+		defer func() {
+			s.tracing.finish(span, getRequestInfo(ctx), ctx.Response.StatusCode())
+		}()
+	}
+	start := time.Now()
 
 	// Process the matched route
 	s.handleRoute(ctx, route)
+
+	elapsed := time.Since(start)
+
+	if s.metrics != nil {
+		s.metrics.observe(strings.ToUpper(route.GetMethod()), route.Path,
+			getRequestInfo(ctx), ctx.Response.StatusCode(), elapsed)
+	}
 }
 
-// parseDelayParam extracts and parses the delay parameter from query string
+// parseDelayParam extracts and parses the delay control, read from the
+// delay query parameter unless controls.delay names another source
 func (s *Server) parseDelayParam(ctx *fasthttp.RequestCtx) (time.Duration, error) {
-	delayStr := string(ctx.QueryArgs().Peek("delay"))
-	if delayStr == "" {
+	values, label := s.controlValues(ctx, configs.ControlDelay, s.config.Controls.Delay)
+	if len(values) == 0 || values[0] == "" {
 		return 0, nil
 	}
+	delayStr := values[0]
 
 	// Try to parse as duration (e.g., "10ms", "1s", "500us")
 	delay, err := time.ParseDuration(delayStr)
@@ -212,7 +482,7 @@ func (s *Server) parseDelayParam(ctx *fasthttp.RequestCtx) (time.Duration, error
 			delay = time.Duration(ms) * time.Millisecond
 			return delay, nil
 		}
-		return 0, err
+		return 0, fmt.Errorf("%s: %w", label, err)
 	}
 
 	return delay, nil
@@ -235,45 +505,75 @@ func (s *Server) sleepWithCancellation(delay time.Duration) bool {
 		return true
 	case <-shutdownChan:
 		// Server is shutting down, return early
-		slog.Debug("Request delay cancelled due to server shutdown",
-			"remaining_delay", delay.String())
 		return false
 	}
 }
 
 // handleRoute processes a matched route and sends the configured response
 func (s *Server) handleRoute(ctx *fasthttp.RequestCtx, route configs.Route) {
-	// Parse and apply delay parameter if present
-	if delay, err := s.parseDelayParam(ctx); err != nil {
-		// Invalid delay parameter, return 400 Bad Request
+	info := getRequestInfo(ctx)
+
+	// Parse the request controls before delaying, so mistakes are reported
+	// right away
+	delay, err := s.parseDelayParam(ctx)
+	var controls responseControls
+	if err == nil {
+		controls, err = s.parseControls(ctx)
+	}
+	if err != nil {
+		// Invalid control, return 400 Bad Request
 		ctx.SetStatusCode(fasthttp.StatusBadRequest)
 		ctx.SetContentType("text/plain")
-		ctx.WriteString("Invalid delay parameter: " + err.Error())
+		ctx.WriteString("Invalid " + err.Error())
 		return
-	} else if delay > 0 {
+	}
+
+	// Apply the delay if present
+	if delay > 0 {
+		info.delay = delay
 		// Apply delay with shutdown cancellation support
 		if !s.sleepWithCancellation(delay) {
 			// Server is shutting down, return early without sending response
+			slog.DebugContext(ctx, "Request delay cancelled due to server shutdown", "delay", delay.String())
+			info.cancelled = true
 			return
 		}
 	}
 
-	// Check if any conditions match the request headers
-	requestHeaders := s.extractHeaders(ctx)
+	// Answer with an injected failure when fail_rate picked this request
+	if controls.fail {
+		slog.DebugContext(ctx, "Injecting failure", "method", route.GetMethod(), "path", route.Path)
+		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+		ctx.SetContentType("text/plain")
+		ctx.WriteString("Injected failure")
+		return
+	}
+
+	// Check if any conditions match the request headers, cookies, client
+	// address and client certificate
+	match := &configs.MatchContext{
+		Headers:    s.extractHeaderValues(ctx),
+		Cookies:    extractCookies(ctx),
+		ClientCert: clientCertificate(ctx),
+		ClientIP:   info.clientIP,
+	}
 
 	var responseBody string
-	var responseHeaders map[string]string
+	var responseHeaders map[string]configs.HeaderValues
+	var responseCookies []configs.Cookie
 	var responseStatus int
 	conditionMatched := false
 
 	// Check conditions first
-	for _, condition := range route.Conditions {
-		if condition.MatchesHeaders(requestHeaders) {
+	for i, condition := range route.Conditions {
+		if condition.Matches(match) {
 			responseBody = condition.GetResponseBody()
 			responseHeaders = condition.GetResponseHeaders()
+			responseCookies = condition.GetResponseCookies()
 			responseStatus = condition.GetResponseStatus()
 			conditionMatched = true
-			slog.Debug("Condition matched", "method", route.GetMethod(), "path", route.Path)
+			info.condition = i
+			slog.DebugContext(ctx, "Condition matched", "method", route.GetMethod(), "path", route.Path)
 			break
 		}
 	}
@@ -282,71 +582,133 @@ func (s *Server) handleRoute(ctx *fasthttp.RequestCtx, route configs.Route) {
 	if !conditionMatched {
 		responseBody = route.GetResponseBody()
 		responseHeaders = route.GetResponseHeaders()
+		responseCookies = route.GetResponseCookies()
 		responseStatus = route.GetResponseStatus()
 	}
 
-	// Set response status code
-	ctx.SetStatusCode(responseStatus)
+	// The size control replaces the body with generated text, taking the
+	// place of an echoed, dumped or generated body
+	generate := route.ResponseGenerate
+	sized := controls.size >= 0
+	if sized {
+		generate = configs.GenerateConfig{Type: configs.GenerateText, Size: strconv.FormatInt(controls.size, 10)}
+	}
+
+	// Echo the request body in place of the configured body
+	var echoContentType string
+	if route.ResponseEcho.Enabled && !sized {
+		body, contentType, err := echoRequestBody(ctx, route.ResponseEcho)
+		if err != nil {
+			// The body cannot be patched, return 400 Bad Request
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+			ctx.SetContentType("text/plain")
+			ctx.WriteString("Cannot echo request body: " + err.Error())
+			return
+		}
+		responseBody, echoContentType = string(body), contentType
+	}
 
-	// Set response headers
-	for key, value := range responseHeaders {
-		ctx.Response.Header.Set(key, value)
+	// Set response status code, unless the client asked for another
+	if controls.status != 0 {
+		responseStatus = controls.status
 	}
+	ctx.SetStatusCode(responseStatus)
 
-	// Set content type if not already set
-	if len(ctx.Response.Header.Peek("Content-Type")) == 0 {
+	// Set response headers, one line per value of a list-valued header
+	for key, values := range responseHeaders {
+		for _, value := range values {
+			ctx.Response.Header.Add(key, value)
+		}
+	}
+	controls.applyHeaders(ctx)
+	setResponseCookies(ctx, responseCookies)
+
+	// Set content type if not already set, an echoed body keeps the request's
+	// and a generated body gets one matching its type. The size control's
+	// text replaces the route's body, so the route's type no longer applies.
+	if sized {
+		ctx.Response.Header.Set("Content-Type", generatedContentTypes[generate.Type])
+	} else if echoContentType != "" {
+		ctx.Response.Header.Set("Content-Type", echoContentType)
+	} else if generate.IsEnabled() && !hasHeader(responseHeaders, "Content-Type") {
+		ctx.Response.Header.Set("Content-Type", generatedContentTypes[generate.Type])
+	} else if len(ctx.Response.Header.Peek("Content-Type")) == 0 {
 		ctx.SetContentType("text/plain")
 	}
 
 	// Handle response dump if enabled for this route
 	finalResponseBody := responseBody
-	if route.GetResponseDump() {
-		requestHeaders := s.extractHeaders(ctx)
-		queryParams := s.extractQueryParameters(ctx)
-
-		dump := RequestDump{
-			Headers:         requestHeaders,
-			QueryParameters: queryParams,
+	if route.GetResponseDump() && !sized {
+		// Without a configured format the client picks one with Accept
+		format := route.GetDumpFormat()
+		if format == "" {
+			format = negotiateDumpFormat(ctx.Request.Header.Peek("Accept"))
+			ctx.Response.Header.Add("Vary", "Accept")
 		}
 
-		dumpJSON, err := json.MarshalIndent(dump, "", "  ")
+		dump, contentType, err := s.renderRequestDump(ctx, route, match, format)
 		if err != nil {
-			slog.Error("Failed to marshal request dump", "error", err)
-		} else {
-			// Replace response body with JSON dump
-			finalResponseBody = string(dumpJSON)
-			// Set content type to JSON when dumping
-			ctx.Response.Header.Set("Content-Type", "application/json")
+			// Never pass the configured body off as a dump
+			slog.ErrorContext(ctx, "Failed to render request dump", "format", format, "error", err)
+			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+			ctx.SetContentType("text/plain")
+			ctx.WriteString("Cannot render request dump: " + err.Error())
+			return
 		}
+		// Replace response body with the dump
+		finalResponseBody = string(dump)
+		ctx.Response.Header.Set("Content-Type", contentType)
 	}
 
-	// Set response body
-	ctx.WriteString(finalResponseBody)
+	// The content_type control replaces the body's type
+	if controls.contentType != "" {
+		ctx.Response.Header.Set("Content-Type", controls.contentType)
+	}
 
-	slog.Debug("Request handled",
-		"method", route.GetMethod(),
-		"path", route.Path,
-		"status", responseStatus,
-		"response_bytes", len(finalResponseBody))
+	// Set response body, generated bodies may be streamed
+	responseBytes := int64(len(finalResponseBody))
+	if generate.IsEnabled() {
+		responseBytes = writeGenerated(ctx, generate)
+	} else {
+		ctx.WriteString(finalResponseBody)
+	}
+
+	attrs := []any{"method", route.GetMethod(), "path", route.Path, "status", responseStatus}
+	if responseBytes >= 0 {
+		attrs = append(attrs, "response_bytes", responseBytes)
+	}
+	slog.DebugContext(ctx, "Request handled", attrs...)
+}
+
+// hasHeader reports whether headers contain name, compared case-insensitively
+func hasHeader(headers map[string]configs.HeaderValues, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
 }
 
-// extractHeaders extracts request headers into a map for condition matching
-func (s *Server) extractHeaders(ctx *fasthttp.RequestCtx) map[string]string {
-	headers := make(map[string]string)
+// extractHeaderValues extracts request headers into a map for condition
+// matching, keeping every value of a repeated header
+func (s *Server) extractHeaderValues(ctx *fasthttp.RequestCtx) map[string][]string {
+	headers := make(map[string][]string)
 
 	ctx.Request.Header.VisitAll(func(key, value []byte) {
-		headers[string(key)] = string(value)
+		headers[string(key)] = append(headers[string(key)], string(value))
 	})
 
 	return headers
 }
 
-// extractQueryParameters extracts query parameters into a map for response dumping
-func (s *Server) extractQueryParameters(ctx *fasthttp.RequestCtx) map[string]string {
-	queryParams := make(map[string]string)
+// extractQueryParameters extracts query parameters into a map for response
+// dumping, keeping every value of a repeated parameter
+func (s *Server) extractQueryParameters(ctx *fasthttp.RequestCtx) map[string][]string {
+	queryParams := make(map[string][]string)
 
 	ctx.QueryArgs().VisitAll(func(key, value []byte) {
-		queryParams[string(key)] = string(value)
+		queryParams[string(key)] = append(queryParams[string(key)], string(value))
 	})
 
 	return queryParams
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -36,7 +37,9 @@ func TestServer_initializeRouter(t *testing.T) {
 	}
 
 	server := &Server{config: config}
-	server.initializeRouter()
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
 
 	// Test that router was initialized
 	if server.router == nil {
@@ -65,6 +68,21 @@ func TestServer_initializeRouter(t *testing.T) {
 	}
 }
 
+func TestServer_initializeRouter_Conflict(t *testing.T) {
+	// Routes the loader would reject, registered without validation
+	server := &Server{config: &configs.ServerConfig{
+		Routes: []configs.Route{
+			{Path: "/api/{id}", Method: "GET"},
+			{Path: "/api/{name}", Method: "GET"},
+		},
+	}}
+
+	err := server.initializeRouter()
+	if err == nil || !strings.Contains(err.Error(), "failed to register routes") {
+		t.Errorf("Expected the router panic as an error, got %v", err)
+	}
+}
+
 func TestServer_RouterIntegration(t *testing.T) {
 	config := &configs.ServerConfig{
 		Address: ":8080",
@@ -78,7 +96,7 @@ func TestServer_RouterIntegration(t *testing.T) {
 				Path:           "/api/users",
 				Method:         "GET",
 				ResponseBody:   `{"users":[]}`,
-				ResponseHeader: map[string]string{"Content-Type": "application/json"},
+				ResponseHeader: map[string]configs.HeaderValues{"Content-Type": {"application/json"}},
 			},
 			{
 				Path: "/default-method",
@@ -87,7 +105,9 @@ func TestServer_RouterIntegration(t *testing.T) {
 	}
 
 	server := &Server{config: config}
-	server.initializeRouter()
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
 
 	tests := []struct {
 		name            string
@@ -175,8 +195,8 @@ func TestServer_handleRoute(t *testing.T) {
 		Path:         "/test",
 		Method:       "GET",
 		ResponseBody: "Test Response",
-		ResponseHeader: map[string]string{
-			"X-Custom": "custom-value",
+		ResponseHeader: map[string]configs.HeaderValues{
+			"X-Custom": {"custom-value"},
 		},
 	}
 
@@ -210,8 +230,8 @@ func TestServer_handleRouteRequest(t *testing.T) {
 		Path:         "/api/test",
 		Method:       "POST",
 		ResponseBody: "Route Test Response",
-		ResponseHeader: map[string]string{
-			"X-Route-Test": "route-value",
+		ResponseHeader: map[string]configs.HeaderValues{
+			"X-Route-Test": {"route-value"},
 		},
 	}
 
@@ -248,7 +268,7 @@ func TestServer_RequestHandler_WithConditions(t *testing.T) {
 				Method:         "GET",
 				ResponseBody:   "Unauthorized",
 				ResponseStatus: 401,
-				ResponseHeader: map[string]string{"Content-Type": "application/json"},
+				ResponseHeader: map[string]configs.HeaderValues{"Content-Type": {"application/json"}},
 				Conditions: []configs.RouteCondition{
 					{
 						HeaderMatch: map[string]string{
@@ -256,9 +276,9 @@ func TestServer_RequestHandler_WithConditions(t *testing.T) {
 						},
 						ResponseBody:   `{"data": "secret information"}`,
 						ResponseStatus: 200,
-						ResponseHeader: map[string]string{
-							"Content-Type": "application/json",
-							"X-Secure":     "true",
+						ResponseHeader: map[string]configs.HeaderValues{
+							"Content-Type": {"application/json"},
+							"X-Secure":     {"true"},
 						},
 					},
 					{
@@ -267,9 +287,9 @@ func TestServer_RequestHandler_WithConditions(t *testing.T) {
 						},
 						ResponseBody:   `{"data": "admin information"}`,
 						ResponseStatus: 200,
-						ResponseHeader: map[string]string{
-							"Content-Type": "application/json",
-							"X-Admin":      "true",
+						ResponseHeader: map[string]configs.HeaderValues{
+							"Content-Type": {"application/json"},
+							"X-Admin":      {"true"},
 						},
 					},
 				},
@@ -278,7 +298,9 @@ func TestServer_RequestHandler_WithConditions(t *testing.T) {
 	}
 
 	server := &Server{config: config}
-	server.initializeRouter()
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
 
 	tests := []struct {
 		name            string
@@ -378,7 +400,7 @@ func TestServer_RequestHandler_WithConditions(t *testing.T) {
 	}
 }
 
-func TestServer_extractHeaders(t *testing.T) {
+func TestServer_extractHeaderValues(t *testing.T) {
 	config := &configs.ServerConfig{}
 	server := &Server{config: config}
 
@@ -386,19 +408,20 @@ func TestServer_extractHeaders(t *testing.T) {
 	ctx.Request.Header.Set("Authorization", "Bearer token123")
 	ctx.Request.Header.Set("Content-Type", "application/json")
 	ctx.Request.Header.Set("X-Custom", "custom-value")
+	ctx.Request.Header.Add("X-Custom", "second-value")
 
-	headers := server.extractHeaders(ctx)
+	headers := server.extractHeaderValues(ctx)
 
-	expectedHeaders := map[string]string{
-		"Authorization": "Bearer token123",
-		"Content-Type":  "application/json",
-		"X-Custom":      "custom-value",
+	expectedHeaders := map[string][]string{
+		"Authorization": {"Bearer token123"},
+		"Content-Type":  {"application/json"},
+		"X-Custom":      {"custom-value", "second-value"},
 	}
 
 	// FastHTTP may add additional headers, so we check that our expected headers are present
 	for expectedKey, expectedValue := range expectedHeaders {
-		if gotValue, exists := headers[expectedKey]; !exists || gotValue != expectedValue {
-			t.Errorf("Expected header %s: %s, got %s (exists: %v)", expectedKey, expectedValue, gotValue, exists)
+		if gotValue, exists := headers[expectedKey]; !exists || !slices.Equal(gotValue, expectedValue) {
+			t.Errorf("Expected header %s: %v, got %v (exists: %v)", expectedKey, expectedValue, gotValue, exists)
 		}
 	}
 }
@@ -496,7 +519,9 @@ func TestServer_RequestHandler_WithDelay(t *testing.T) {
 	}
 
 	server := &Server{config: config}
-	server.initializeRouter()
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
 
 	tests := []struct {
 		name           string
@@ -722,7 +747,7 @@ func TestSetupLogger(t *testing.T) {
 			os.Stdout = w
 
 			// Setup logger with test level
-			setupLogger(tt.level)
+			setupLogger(tt.level, "text")
 
 			// Test that the logger respects the configured level
 			// by attempting to log at different levels
@@ -1038,15 +1063,15 @@ func TestServer_handleRoute_WithResponseDump(t *testing.T) {
 			t.Errorf("Expected query_parameters in JSON dump, but not found: %q", body)
 		}
 
-		if !strings.Contains(body, `"X-Custom": "custom-value"`) {
+		if !strings.Contains(body, "\"X-Custom\": [\n      \"custom-value\"") {
 			t.Errorf("Expected X-Custom header in dump, but not found: %q", body)
 		}
 
-		if !strings.Contains(body, `"param1": "value1"`) {
+		if !strings.Contains(body, "\"param1\": [\n      \"value1\"") {
 			t.Errorf("Expected param1 query parameter in dump, but not found: %q", body)
 		}
 
-		if !strings.Contains(body, `"param2": "value2"`) {
+		if !strings.Contains(body, "\"param2\": [\n      \"value2\"") {
 			t.Errorf("Expected param2 query parameter in dump, but not found: %q", body)
 		}
 
@@ -1086,11 +1111,11 @@ func TestServer_handleRoute_WithResponseDump(t *testing.T) {
 			t.Errorf("Expected headers in JSON dump, but not found: %q", body)
 		}
 
-		if !strings.Contains(body, `"Authorization": "Bearer token123"`) {
+		if !strings.Contains(body, "\"Authorization\": [\n      \"Bearer token123\"") {
 			t.Errorf("Expected Authorization header in dump, but not found: %q", body)
 		}
 
-		if !strings.Contains(body, `"debug": "true"`) {
+		if !strings.Contains(body, "\"debug\": [\n      \"true\"") {
 			t.Errorf("Expected debug query parameter in dump, but not found: %q", body)
 		}
 
@@ -1101,3 +1126,120 @@ func TestServer_handleRoute_WithResponseDump(t *testing.T) {
 		}
 	})
 }
+
+// TestServer_Handler_Hosts tests dispatching requests to the routes of the host they were sent to
+func TestServer_Handler_Hosts(t *testing.T) {
+	config := &configs.ServerConfig{
+		Metrics: configs.MetricsConfig{Enabled: true, Path: "/metrics"},
+		Routes: []configs.Route{
+			{Path: "/whoami", Method: "GET", ResponseBody: "any host"},
+			{Path: "/whoami", Method: "GET", Host: "api.example.test", ResponseBody: "api"},
+			{Path: "/whoami", Method: "GET", Host: "*.partner.test", ResponseBody: "partner"},
+			{Path: "/whoami", Method: "GET", Host: "*.eu.partner.test", ResponseBody: "eu partner"},
+			{Path: "/only-api", Method: "POST", Host: "api.example.test", ResponseBody: "api only"},
+			{Path: "/shared", Method: "GET", ResponseBody: "shared"},
+		},
+	}
+
+	server := &Server{config: config, metrics: newMetrics()}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+
+	request := func(method, host, path string) (int, string) {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(path)
+		ctx.Request.Header.SetMethod(method)
+		ctx.Request.Header.SetHost(host)
+		server.Handler(ctx)
+		return ctx.Response.StatusCode(), string(ctx.Response.Body())
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		host           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"exact host", "GET", "api.example.test", "/whoami", 200, "api"},
+		{"exact host with port", "GET", "API.example.test:12330", "/whoami", 200, "api"},
+		{"wildcard host", "GET", "acme.partner.test", "/whoami", 200, "partner"},
+		{"longer wildcard wins", "GET", "acme.eu.partner.test", "/whoami", 200, "eu partner"},
+		{"unknown host", "GET", "localhost", "/whoami", 200, "any host"},
+		{"host falls back to routes for any host", "GET", "api.example.test", "/shared", 200, "shared"},
+		{"metrics for any host", "GET", "api.example.test", "/metrics", 200, ""},
+		{"host route hidden from other hosts", "POST", "localhost", "/only-api", 404, "404 Not Found"},
+		{"other method falls back", "GET", "api.example.test", "/only-api", 404, "404 Not Found"},
+		{"host-only method", "POST", "api.example.test", "/only-api", 200, "api only"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := request(tt.method, tt.host, tt.path)
+			if status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+			if tt.expectedBody != "" && body != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, body)
+			}
+		})
+	}
+}
+
+func TestServer_MultiValueHeaders(t *testing.T) {
+	config := &configs.ServerConfig{
+		Routes: []configs.Route{
+			{
+				Path:   "/assets",
+				Method: "GET",
+				ResponseHeader: map[string]configs.HeaderValues{
+					"Link": {"</a.css>; rel=preload", "</b.js>; rel=preload"},
+				},
+				ResponseBody: "stable",
+				Conditions: []configs.RouteCondition{
+					{
+						HeaderMatch:  map[string]string{"X-Feature": "beta"},
+						ResponseBody: "beta",
+					},
+				},
+			},
+		},
+	}
+	server := &Server{config: config}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+
+	request := func(features ...string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI("/assets")
+		ctx.Request.Header.SetMethod("GET")
+		for _, feature := range features {
+			ctx.Request.Header.Add("X-Feature", feature)
+		}
+		server.Handler(ctx)
+		return ctx
+	}
+
+	t.Run("every response header value is sent", func(t *testing.T) {
+		var links []string
+		for _, value := range request().Response.Header.PeekAll("Link") {
+			links = append(links, string(value))
+		}
+		expected := []string{"</a.css>; rel=preload", "</b.js>; rel=preload"}
+		if strings.Join(links, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("Expected Link headers %v, got %v", expected, links)
+		}
+	})
+
+	t.Run("repeated request header matches any value", func(t *testing.T) {
+		if got := string(request("alpha", "beta").Response.Body()); got != "beta" {
+			t.Errorf("Expected beta response, got %q", got)
+		}
+		if got := string(request("alpha", "gamma").Response.Body()); got != "stable" {
+			t.Errorf("Expected stable response, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// requestLabels are the labels of the per-route request metrics
+var requestLabels = []string{"method", "path", "status", "condition"}
+
+// metrics holds the Prometheus collectors describing the traffic echo2
+// received. A single instance outlives config refreshes so counters keep
+// accumulating across them.
+type metrics struct {
+	registry  *prometheus.Registry
+	requests  *prometheus.CounterVec   // Requests per route, status and matched condition
+	duration  *prometheus.HistogramVec // Handling latency, including injected delays
+	inFlight  prometheus.Gauge         // Requests currently being handled
+	delayed   *prometheus.CounterVec   // Requests that asked for a delay
+	cancelled *prometheus.CounterVec   // Delays cut short by server shutdown
+	handler   fasthttp.RequestHandler  // Serves the text exposition format
+}
+
+// newMetrics creates and registers all collectors on a dedicated registry
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "echo2_requests_total",
+			Help: "Requests handled by configured routes.",
+		}, requestLabels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "echo2_request_duration_seconds",
+			Help:    "Time spent handling requests, including injected delays.",
+			Buckets: append(prometheus.DefBuckets, 30, 60),
+		}, requestLabels),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "echo2_requests_in_flight",
+			Help: "Requests currently being handled.",
+		}),
+		delayed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "echo2_delayed_requests_total",
+			Help: "Requests that were delayed with the delay parameter.",
+		}, []string{"method", "path"}),
+		cancelled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "echo2_cancelled_requests_total",
+			Help: "Delayed requests cancelled because the server was shutting down.",
+		}, []string{"method", "path"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests, m.duration, m.inFlight, m.delayed, m.cancelled,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	m.handler = fasthttpadaptor.NewFastHTTPHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+
+	return m
+}
+
+// observe records a finished request for the route registered as method and path
+func (m *metrics) observe(method, path string, info *requestInfo, status int, elapsed time.Duration) {
+	condition := "default"
+	if info.condition >= 0 {
+		condition = strconv.Itoa(info.condition)
+	}
+
+	labels := prometheus.Labels{
+		"method":    method,
+		"path":      path,
+		"status":    strconv.Itoa(status),
+		"condition": condition,
+	}
+	m.requests.With(labels).Inc()
+	m.duration.With(labels).Observe(elapsed.Seconds())
+
+	if info.delay > 0 {
+		m.delayed.WithLabelValues(method, path).Inc()
+	}
+	if info.cancelled {
+		m.cancelled.WithLabelValues(method, path).Inc()
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+func TestServer_Metrics(t *testing.T) {
+	config := &configs.ServerConfig{
+		Metrics: configs.MetricsConfig{Enabled: true, Path: "/metrics"},
+		Routes: []configs.Route{
+			{
+				Path:         "/api/{id}",
+				Method:       "GET",
+				ResponseBody: "default",
+				Conditions: []configs.RouteCondition{
+					{
+						HeaderMatch:    map[string]string{"X-Test": "teapot"},
+						ResponseStatus: 418,
+					},
+				},
+			},
+		},
+	}
+
+	server := &Server{config: config, metrics: newMetrics()}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+
+	request := func(uri string, headers map[string]string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(uri)
+		ctx.Request.Header.SetMethod("GET")
+		for key, value := range headers {
+			ctx.Request.Header.Set(key, value)
+		}
+		server.router.Handler(ctx)
+		return ctx
+	}
+
+	request("/api/1", nil)
+	request("/api/2?delay=1ms", nil)
+	request("/api/3", map[string]string{"X-Test": "teapot"})
+
+	ctx := request("/metrics", nil)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("Expected status 200 from metrics endpoint, got %d", ctx.Response.StatusCode())
+	}
+
+	body := string(ctx.Response.Body())
+	expected := []string{
+		`echo2_requests_total{condition="default",method="GET",path="/api/{id}",status="200"} 2`,
+		`echo2_requests_total{condition="0",method="GET",path="/api/{id}",status="418"} 1`,
+		`echo2_request_duration_seconds_count{condition="default",method="GET",path="/api/{id}",status="200"} 2`,
+		`echo2_delayed_requests_total{method="GET",path="/api/{id}"} 1`,
+		`echo2_requests_in_flight 0`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", line, body)
+		}
+	}
+
+	t.Run("endpoint disabled", func(t *testing.T) {
+		config.Metrics.Enabled = false
+		if err := server.initializeRouter(); err != nil {
+			t.Fatalf("initializeRouter() error = %v", err)
+		}
+
+		ctx := request("/metrics", nil)
+		if ctx.Response.StatusCode() != fasthttp.StatusNotFound {
+			t.Errorf("Expected status 404 with metrics disabled, got %d", ctx.Response.StatusCode())
+		}
+	})
+}
+
+func TestServer_Metrics_CancelledDelay(t *testing.T) {
+	originalShutdownChan := shutdownChan
+	shutdownChan = make(chan struct{})
+	defer func() { shutdownChan = originalShutdownChan }()
+
+	server := &Server{config: &configs.ServerConfig{}, metrics: newMetrics()}
+	route := configs.Route{Path: "/slow", Method: "GET"}
+
+	close(shutdownChan)
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/slow?delay=1m")
+	server.handleRouteRequest(ctx, route)
+
+	info := getRequestInfo(ctx)
+	if !info.cancelled || info.delay != time.Minute {
+		t.Errorf("Expected cancelled 1m delay, got %+v", info)
+	}
+
+	server.config.Metrics = configs.MetricsConfig{Enabled: true, Path: "/metrics"}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+	metricsCtx := &fasthttp.RequestCtx{}
+	metricsCtx.Request.SetRequestURI("/metrics")
+	server.router.Handler(metricsCtx)
+
+	expected := `echo2_cancelled_requests_total{method="GET",path="/slow"} 1`
+	if !strings.Contains(string(metricsCtx.Response.Body()), expected) {
+		t.Errorf("Expected metrics to contain %q", expected)
+	}
+}
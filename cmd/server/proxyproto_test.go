@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+// proxyV2Header builds a v2 header with the given command, family and
+// address block
+func proxyV2Header(command, family byte, addresses []byte) []byte {
+	header := append([]byte{}, proxyV2Signature...)
+	header = append(header, 0x20|command, family)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(addresses)))
+	return append(header, addresses...)
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	ipv4 := append(net.ParseIP("192.0.2.1").To4(), net.ParseIP("198.51.100.1").To4()...)
+	ipv4 = binary.BigEndian.AppendUint16(ipv4, 56324)
+	ipv4 = binary.BigEndian.AppendUint16(ipv4, 443)
+	ipv6 := append(net.ParseIP("2001:db8::1").To16(), net.ParseIP("2001:db8::2").To16()...)
+	ipv6 = binary.BigEndian.AppendUint16(ipv6, 56324)
+	ipv6 = binary.BigEndian.AppendUint16(ipv6, 443)
+	// A TLV after the addresses is skipped
+	ipv4WithTLV := append(append([]byte{}, ipv4...), 0x04, 0x00, 0x01, 0xff)
+
+	tests := []struct {
+		name     string
+		header   []byte
+		expected string // Source address, empty when the peer's address is kept
+		err      string
+	}{
+		{"v1 tcp4", []byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n"), "192.0.2.1:56324", ""},
+		{"v1 tcp6", []byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n"), "[2001:db8::1]:56324", ""},
+		{"v1 unknown", []byte("PROXY UNKNOWN\r\n"), "", ""},
+		{"v1 without crlf", []byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\n"), "", "not terminated by CRLF"},
+		{"v1 too long", []byte("PROXY TCP4 " + strings.Repeat("1", 120) + "\r\n"), "", "not terminated by CRLF"},
+		{"v1 bad address", []byte("PROXY TCP4 host 198.51.100.1 56324 443\r\n"), "", "invalid v1 source address"},
+		{"v2 ipv4", proxyV2Header(0x1, 0x11, ipv4), "192.0.2.1:56324", ""},
+		{"v2 ipv4 with tlv", proxyV2Header(0x1, 0x11, ipv4WithTLV), "192.0.2.1:56324", ""},
+		{"v2 ipv6", proxyV2Header(0x1, 0x21, ipv6), "[2001:db8::1]:56324", ""},
+		{"v2 local", proxyV2Header(0x0, 0x00, nil), "", ""},
+		{"v2 short address block", proxyV2Header(0x1, 0x11, ipv4[:8]), "", "short IPv4 address block"},
+		{"missing header", []byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n"), "", "missing header"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := "GET / HTTP/1.1\r\n\r\n"
+			r := bufio.NewReader(bytes.NewReader(append(tt.header, request...)))
+
+			addr, err := readProxyHeader(r)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			got := ""
+			if addr != nil {
+				got = addr.String()
+			}
+			if got != tt.expected {
+				t.Errorf("Expected source address %q, got %q", tt.expected, got)
+			}
+
+			// The request that follows the header is left unread
+			rest, _ := io.ReadAll(r)
+			if string(rest) != request {
+				t.Errorf("Expected the request to follow the header, got %q", rest)
+			}
+		})
+	}
+}
+
+func TestProxyListener(t *testing.T) {
+	config := &configs.ServerConfig{
+		TrustedProxies: []string{"192.0.2.0/24"},
+		Routes:         []configs.Route{{Path: "/dump", Method: "GET", ResponseDump: true}},
+	}
+	server := &Server{config: config}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+	httpServer := &fasthttp.Server{Handler: server.Handler, Logger: log.New(io.Discard, "", 0)}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go serveListener(httpServer, &proxyListener{ln})
+	defer httpServer.Shutdown()
+
+	send := func(t *testing.T, payload string) string {
+		t.Helper()
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		defer conn.Close()
+		if _, err := conn.Write([]byte(payload)); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+		response, _ := io.ReadAll(conn)
+		return string(response)
+	}
+	clientIP := func(t *testing.T, response string) string {
+		t.Helper()
+		_, body, ok := strings.Cut(response, "\r\n\r\n")
+		if !ok {
+			t.Fatalf("Expected an HTTP response, got %q", response)
+		}
+		var dump RequestDump
+		if err := json.Unmarshal([]byte(body), &dump); err != nil {
+			t.Fatalf("Failed to parse dump %q: %v", body, err)
+		}
+		return dump.ClientIP
+	}
+	request := "GET /dump HTTP/1.1\r\nHost: echo2\r\nConnection: close\r\n"
+
+	t.Run("source address from header", func(t *testing.T) {
+		response := send(t, "PROXY TCP4 203.0.113.9 127.0.0.1 40000 80\r\n"+request+"\r\n")
+		if got := clientIP(t, response); got != "203.0.113.9" {
+			t.Errorf("Expected client IP from PROXY header, got %q", got)
+		}
+	})
+
+	t.Run("trusted proxy in header forwards for client", func(t *testing.T) {
+		response := send(t, "PROXY TCP4 192.0.2.10 127.0.0.1 40000 80\r\n"+request+"X-Forwarded-For: 198.51.100.4\r\n\r\n")
+		if got := clientIP(t, response); got != "198.51.100.4" {
+			t.Errorf("Expected client IP from X-Forwarded-For, got %q", got)
+		}
+	})
+
+	t.Run("connection without header is rejected", func(t *testing.T) {
+		if response := send(t, request+"\r\n"); !strings.HasPrefix(response, "HTTP/1.1 400") {
+			t.Errorf("Expected 400 for a connection without PROXY header, got %q", response)
+		}
+	})
+}
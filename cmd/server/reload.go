@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+// reloadableHandler dispatches requests to the most recently loaded server so
+// that a refreshed configuration can replace the routes without restarting
+// the listener. Requests already in flight finish on the server they started on.
+type reloadableHandler struct {
+	current atomic.Pointer[Server]
+}
+
+// newReloadableHandler creates a handler serving requests with s
+func newReloadableHandler(s *Server) *reloadableHandler {
+	h := &reloadableHandler{}
+	h.current.Store(s)
+	return h
+}
+
+// Handler serves a request with the current server's routers
+func (h *reloadableHandler) Handler(ctx *fasthttp.RequestCtx) {
+	h.current.Load().Handler(ctx)
+}
+
+// reload loads the configuration again and swaps in a new router. On error
+// the current configuration stays in place.
+func (h *reloadableHandler) reload(paths []string) error {
+	return reloadHandlers([]*reloadableHandler{h}, paths)
+}
+
+// reloadHandlers loads the configuration once and swaps a new router into
+// the handler of every listener. On error the current configuration stays in
+// place. The listeners, TLS, PROXY protocol, logging, metrics and tracing
+// settings cannot change without a restart, so they are kept, and the result
+// is validated again.
+func reloadHandlers(handlers []*reloadableHandler, paths []string) error {
+	config, err := configs.LoadConfigs(paths...)
+	if err != nil {
+		return err
+	}
+
+	previous := handlers[0].current.Load().config
+	if config.Address != previous.Address {
+		slog.Warn("Config address changed, restart the server to apply it",
+			"address", previous.Address, "new_address", config.Address)
+		config.Address = previous.Address
+	}
+	if !reflect.DeepEqual(config.Listeners, previous.Listeners) {
+		slog.Warn("Config listeners changed, restart the server to apply them")
+		config.Listeners = previous.Listeners
+
+		// A route on a listener that is not running would silently never
+		// be served
+		for _, route := range config.Routes {
+			for _, name := range route.Listeners {
+				if !slices.ContainsFunc(previous.Listeners, func(l configs.Listener) bool { return l.Name == name }) {
+					return fmt.Errorf("route %s %s (%s) uses listener '%s', which is not running, restart the server to add it",
+						route.GetMethod(), route.Path, route.Source(), name)
+				}
+			}
+		}
+	}
+	if config.GetLogLevel() != previous.GetLogLevel() {
+		slog.Warn("Config log level changed, restart the server to apply it",
+			"log_level", previous.GetLogLevel(), "new_log_level", config.GetLogLevel())
+		config.LogLevel = previous.LogLevel
+	}
+	if config.GetLogFormat() != previous.GetLogFormat() {
+		slog.Warn("Config log format changed, restart the server to apply it",
+			"log_format", previous.GetLogFormat(), "new_log_format", config.GetLogFormat())
+		config.LogFormat = previous.LogFormat
+	}
+	if !reflect.DeepEqual(config.AccessLog, previous.AccessLog) {
+		slog.Warn("Config access log changed, restart the server to apply it")
+		config.AccessLog = previous.AccessLog
+	}
+	if !reflect.DeepEqual(config.TLS, previous.TLS) {
+		slog.Warn("Config TLS settings changed, restart the server to apply them")
+		config.TLS = previous.TLS
+	}
+	if config.ProxyProtocol != previous.ProxyProtocol {
+		slog.Warn("Config proxy_protocol changed, restart the server to apply it")
+		config.ProxyProtocol = previous.ProxyProtocol
+	}
+	if config.Metrics.Enabled != previous.Metrics.Enabled {
+		slog.Warn("Config metrics.enabled changed, restart the server to apply it")
+		config.Metrics.Enabled = previous.Metrics.Enabled
+	}
+	if !reflect.DeepEqual(config.Tracing, previous.Tracing) {
+		slog.Warn("Config tracing changed, restart the server to apply it")
+		config.Tracing = previous.Tracing
+	}
+
+	// The routes may conflict with the settings that were kept, such as a
+	// route on the metrics path of a server that still serves metrics
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	// Build every router before swapping any, so that a failure leaves all
+	// listeners on the previous config
+	servers := make([]*Server, len(handlers))
+	for i, h := range handlers {
+		current := h.current.Load()
+		servers[i] = current.forListener(config, current.listener)
+		if err := servers[i].initializeRouter(); err != nil {
+			return err
+		}
+	}
+	for i, h := range handlers {
+		h.current.Store(servers[i])
+	}
+
+	slog.Debug("Refreshed config", "routes", len(config.Routes))
+	return nil
+}
+
+// refreshConfig reloads the configuration of every listener's handler each
+// interval until the server shuts down
+func refreshConfig(handlers []*reloadableHandler, paths []string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := reloadHandlers(handlers, paths); err != nil {
+				slog.Error("Failed to refresh config, keeping the previous one", "error", err)
+			}
+		case <-shutdownChan:
+			return
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+func TestReloadableHandler_reload(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(content string) {
+		if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+	}
+
+	writeConfig(`address: ":8080"
+routes:
+  - path: "/health"
+    response_body: "v1"
+`)
+	config, err := configs.LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	server := &Server{config: config}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+	handler := newReloadableHandler(server)
+
+	get := func(path string) (int, string) {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(path)
+		ctx.Request.Header.SetMethod("GET")
+		handler.Handler(ctx)
+		return ctx.Response.StatusCode(), string(ctx.Response.Body())
+	}
+
+	if _, body := get("/health"); body != "v1" {
+		t.Fatalf("Expected body v1, got %q", body)
+	}
+
+	t.Run("valid config replaces routes", func(t *testing.T) {
+		writeConfig(`address: ":9090"
+routes:
+  - path: "/health"
+    response_body: "v2"
+  - path: "/new"
+    response_body: "new"
+`)
+		if err := handler.reload([]string{configFile}); err != nil {
+			t.Fatalf("reload() error = %v", err)
+		}
+
+		if _, body := get("/health"); body != "v2" {
+			t.Errorf("Expected body v2, got %q", body)
+		}
+		if status, body := get("/new"); status != fasthttp.StatusOK || body != "new" {
+			t.Errorf("Expected 200 new, got %d %q", status, body)
+		}
+		if got := handler.current.Load().config.Address; got != ":8080" {
+			t.Errorf("Expected address to stay :8080, got %s", got)
+		}
+	})
+
+	t.Run("invalid config keeps previous routes", func(t *testing.T) {
+		writeConfig(`routes:
+  - path: "/health"
+    method: "FETCH"
+`)
+		if err := handler.reload([]string{configFile}); err == nil {
+			t.Fatal("Expected reload error, got nil")
+		}
+
+		if _, body := get("/health"); body != "v2" {
+			t.Errorf("Expected previous body v2, got %q", body)
+		}
+	})
+
+	t.Run("metrics stay disabled until restart", func(t *testing.T) {
+		writeConfig(`metrics:
+  enabled: true
+routes:
+  - path: "/health"
+    response_body: "v3"
+`)
+		if err := handler.reload([]string{configFile}); err != nil {
+			t.Fatalf("reload() error = %v", err)
+		}
+
+		if _, body := get("/health"); body != "v3" {
+			t.Errorf("Expected body v3, got %q", body)
+		}
+		if status, _ := get("/metrics"); status != fasthttp.StatusNotFound {
+			t.Errorf("Expected no metrics endpoint without collectors, got %d", status)
+		}
+		if handler.current.Load().config.Metrics.Enabled {
+			t.Error("Expected metrics.enabled to be kept until restart")
+		}
+	})
+}
+
+func TestReloadHandlers_Listeners(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(content string) {
+		if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+	}
+
+	writeConfig(`listeners:
+  - name: payments
+    address: ":9001"
+  - name: users
+    address: ":9002"
+routes:
+  - path: "/api/{id}"
+    response_body: "payment"
+    listeners: [payments]
+  - path: "/api/{name}"
+    response_body: "user"
+    listeners: [users]
+  - path: "/health"
+    response_body: "v1"
+`)
+	config, err := configs.LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	shared := &Server{config: config}
+	handlers := make(map[string]*reloadableHandler)
+	var all []*reloadableHandler
+	for _, listener := range config.GetListeners() {
+		server := shared.forListener(config, listener.Name)
+		if err := server.initializeRouter(); err != nil {
+			t.Fatalf("initializeRouter() error = %v", err)
+		}
+		handlers[listener.Name] = newReloadableHandler(server)
+		all = append(all, handlers[listener.Name])
+	}
+
+	get := func(listener, path string) (int, string) {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(path)
+		ctx.Request.Header.SetMethod("GET")
+		handlers[listener].Handler(ctx)
+		return ctx.Response.StatusCode(), string(ctx.Response.Body())
+	}
+
+	if _, body := get("payments", "/api/1"); body != "payment" {
+		t.Errorf("Expected payments listener to serve payment, got %q", body)
+	}
+	if _, body := get("users", "/api/1"); body != "user" {
+		t.Errorf("Expected users listener to serve user, got %q", body)
+	}
+	for _, listener := range []string{"payments", "users"} {
+		if _, body := get(listener, "/health"); body != "v1" {
+			t.Errorf("Expected %s listener to serve the shared route, got %q", listener, body)
+		}
+	}
+
+	writeConfig(`listeners:
+  - name: payments
+    address: ":9001"
+  - name: users
+    address: ":9002"
+  - name: orders
+    address: ":9003"
+routes:
+  - path: "/health"
+    response_body: "v2"
+    listeners: [payments]
+`)
+	if err := reloadHandlers(all, []string{configFile}); err != nil {
+		t.Fatalf("reloadHandlers() error = %v", err)
+	}
+
+	if _, body := get("payments", "/health"); body != "v2" {
+		t.Errorf("Expected refreshed route on payments, got %q", body)
+	}
+	if status, _ := get("users", "/health"); status != fasthttp.StatusNotFound {
+		t.Errorf("Expected route removed from users to return 404, got %d", status)
+	}
+	if got := len(handlers["users"].current.Load().config.Listeners); got != 2 {
+		t.Errorf("Expected listeners to be kept until restart, got %d", got)
+	}
+
+	writeConfig(`listeners:
+  - name: payments
+    address: ":9001"
+  - name: users
+    address: ":9002"
+  - name: orders
+    address: ":9003"
+routes:
+  - path: "/health"
+    response_body: "v3"
+    listeners: [payments]
+  - path: "/orders"
+    response_body: "orders"
+    listeners: [orders]
+`)
+	err = reloadHandlers(all, []string{configFile})
+	if err == nil || !strings.Contains(err.Error(), "uses listener 'orders', which is not running") {
+		t.Fatalf("Expected a reload naming a new listener to fail, got %v", err)
+	}
+	if _, body := get("payments", "/health"); body != "v2" {
+		t.Errorf("Expected the previous routes to be kept, got %q", body)
+	}
+}
+
+func TestReloadHandlers_KeptSettings(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(content string) {
+		if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+	}
+
+	writeConfig(`metrics:
+  enabled: true
+routes:
+  - path: "/health"
+    response_body: "v1"
+`)
+	config, err := configs.LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	server := &Server{config: config, metrics: newMetrics()}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+	handler := newReloadableHandler(server)
+
+	// Valid on its own, but the route takes the path of the metrics endpoint
+	// that stays enabled until restart
+	writeConfig(`routes:
+  - path: "/health"
+    response_body: "v2"
+  - path: "/metrics"
+    method: "GET"
+    response_body: "not metrics"
+`)
+	err = handler.reload([]string{configFile})
+	if err == nil || !strings.Contains(err.Error(), "metrics.path") {
+		t.Fatalf("Expected a metrics.path conflict, got %v", err)
+	}
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/health")
+	handler.Handler(ctx)
+	if body := string(ctx.Response.Body()); body != "v1" {
+		t.Errorf("Expected previous body v1, got %q", body)
+	}
+}
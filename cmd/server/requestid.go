@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+)
+
+// maxRequestIDLength bounds incoming request IDs so a client cannot bloat
+// every log record of its request
+const maxRequestIDLength = 256
+
+// assignRequestID takes the request ID from header, generating a UUID when
+// the request has none, records it in the request's info and echoes it in
+// the same response header
+func assignRequestID(ctx *fasthttp.RequestCtx, header string) string {
+	id := string(ctx.Request.Header.Peek(header))
+	if id == "" || len(id) > maxRequestIDLength {
+		id = uuid.NewString()
+	}
+
+	getRequestInfo(ctx).requestID = id
+	ctx.Response.Header.Set(header, id)
+	return id
+}
+
+// requestIDHandler adds the ID of the request being handled to every record
+// logged with the request's context (e.g. slog.DebugContext(ctx, ...))
+type requestIDHandler struct {
+	slog.Handler
+}
+
+// Handle adds a request_id attribute when ctx belongs to a request with an ID
+func (h requestIDHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if info, ok := ctx.Value(requestInfoKey).(*requestInfo); ok && info.requestID != "" {
+			r.AddAttrs(slog.String("request_id", info.requestID))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestIDHandler) WithGroup(name string) slog.Handler {
+	return requestIDHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+func TestServer_RequestID(t *testing.T) {
+	config := &configs.ServerConfig{
+		LogLevel: "debug",
+		Routes: []configs.Route{
+			{Path: "/dump", Method: "GET", ResponseDump: true},
+		},
+	}
+
+	// Capture the application log as JSON
+	var buf bytes.Buffer
+	originalLogger := slog.Default()
+	slog.SetDefault(slog.New(newLogHandler(&buf, "json", &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(originalLogger)
+
+	requestPath := func(server *Server, uri string, headers map[string]string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(uri)
+		ctx.Request.Header.SetMethod("GET")
+		for key, value := range headers {
+			ctx.Request.Header.Set(key, value)
+		}
+		server.Handler(ctx)
+		return ctx
+	}
+	request := func(server *Server, headers map[string]string) *fasthttp.RequestCtx {
+		return requestPath(server, "/dump", headers)
+	}
+
+	t.Run("incoming ID", func(t *testing.T) {
+		server := &Server{config: config}
+		if err := server.initializeRouter(); err != nil {
+			t.Fatalf("initializeRouter() error = %v", err)
+		}
+		buf.Reset()
+
+		ctx := request(server, map[string]string{"X-Request-ID": "abc-123"})
+
+		if got := string(ctx.Response.Header.Peek("X-Request-ID")); got != "abc-123" {
+			t.Errorf("Expected X-Request-ID response header 'abc-123', got %q", got)
+		}
+
+		var dump RequestDump
+		if err := json.Unmarshal(ctx.Response.Body(), &dump); err != nil {
+			t.Fatalf("Failed to parse dump: %v", err)
+		}
+		if dump.RequestID != "abc-123" {
+			t.Errorf("Expected request_id 'abc-123' in dump, got %q", dump.RequestID)
+		}
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) < 2 {
+			t.Fatalf("Expected request log records, got %q", buf.String())
+		}
+		for _, line := range lines {
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("Failed to parse log record %q: %v", line, err)
+			}
+			if record["request_id"] != "abc-123" {
+				t.Errorf("Expected request_id on every record, got %q", line)
+			}
+		}
+	})
+
+	t.Run("generated ID", func(t *testing.T) {
+		server := &Server{config: config}
+		if err := server.initializeRouter(); err != nil {
+			t.Fatalf("initializeRouter() error = %v", err)
+		}
+
+		ctx := request(server, nil)
+		id := string(ctx.Response.Header.Peek("X-Request-ID"))
+		if _, err := uuid.Parse(id); err != nil {
+			t.Errorf("Expected a generated UUID, got %q", id)
+		}
+
+		other := request(server, nil)
+		if string(other.Response.Header.Peek("X-Request-ID")) == id {
+			t.Error("Expected a new ID for every request")
+		}
+	})
+
+	t.Run("custom header", func(t *testing.T) {
+		custom := *config
+		custom.RequestID.Header = "X-Correlation-ID"
+		server := &Server{config: &custom}
+		if err := server.initializeRouter(); err != nil {
+			t.Fatalf("initializeRouter() error = %v", err)
+		}
+
+		ctx := request(server, map[string]string{"X-Correlation-ID": "corr-1", "X-Request-ID": "ignored"})
+		if got := string(ctx.Response.Header.Peek("X-Correlation-ID")); got != "corr-1" {
+			t.Errorf("Expected X-Correlation-ID response header 'corr-1', got %q", got)
+		}
+		if got := ctx.Response.Header.Peek("X-Request-ID"); len(got) != 0 {
+			t.Errorf("Expected no X-Request-ID response header, got %q", got)
+		}
+	})
+
+	t.Run("unmatched requests", func(t *testing.T) {
+		server := &Server{config: config}
+		if err := server.initializeRouter(); err != nil {
+			t.Fatalf("initializeRouter() error = %v", err)
+		}
+
+		ctx := requestPath(server, "/missing", map[string]string{"X-Request-ID": "abc-404"})
+		if ctx.Response.StatusCode() != fasthttp.StatusNotFound {
+			t.Fatalf("Expected 404, got %d", ctx.Response.StatusCode())
+		}
+		if got := string(ctx.Response.Header.Peek("X-Request-ID")); got != "abc-404" {
+			t.Errorf("Expected X-Request-ID response header 'abc-404' on a 404, got %q", got)
+		}
+
+		ctx = requestPath(server, "/missing", nil)
+		if _, err := uuid.Parse(string(ctx.Response.Header.Peek("X-Request-ID"))); err != nil {
+			t.Errorf("Expected a generated UUID on a 404, got %q", ctx.Response.Header.Peek("X-Request-ID"))
+		}
+	})
+
+	t.Run("oversized ID replaced", func(t *testing.T) {
+		server := &Server{config: config}
+		if err := server.initializeRouter(); err != nil {
+			t.Fatalf("initializeRouter() error = %v", err)
+		}
+
+		ctx := request(server, map[string]string{"X-Request-ID": strings.Repeat("x", maxRequestIDLength+1)})
+		if got := string(ctx.Response.Header.Peek("X-Request-ID")); len(got) > maxRequestIDLength {
+			t.Errorf("Expected an oversized ID to be replaced, got %d bytes", len(got))
+		}
+	})
+}
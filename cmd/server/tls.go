@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+// selfSignedValidity is how long a generated certificate is valid for
+var selfSignedValidity = 365 * 24 * time.Hour
+
+// clientAuthTypes maps tls.client_auth values to the crypto/tls modes
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	configs.ClientAuthNone:             tls.NoClientCert,
+	configs.ClientAuthRequest:          tls.RequestClientCert,
+	configs.ClientAuthRequire:          tls.RequireAnyClientCert,
+	configs.ClientAuthVerifyIfGiven:    tls.VerifyClientCertIfGiven,
+	configs.ClientAuthRequireAndVerify: tls.RequireAndVerifyClientCert,
+}
+
+// newTLSConfig builds the listener's TLS configuration, loading or
+// generating its certificate and the CA pool used to verify clients
+func newTLSConfig(config configs.TLSConfig) (*tls.Config, error) {
+	var cert tls.Certificate
+	var err error
+	if config.SelfSigned {
+		cert, err = generateSelfSignedCert(config.SelfSignedHosts)
+	} else {
+		cert, err = tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   clientAuthTypes[config.GetClientAuth()],
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if config.ClientCAFile != "" {
+		data, err := os.ReadFile(config.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates found in client CA file %s", config.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// generateSelfSignedCert creates an ECDSA certificate valid for localhost,
+// the loopback addresses and the given extra DNS names and IPs
+func generateSelfSignedCert(hosts []string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "echo2 self-signed", Organization: []string{"echo2"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, nil
+}
+
+// certificateFingerprint returns the SHA-256 fingerprint of cert's leaf, so
+// clients can pin a generated certificate
+func certificateFingerprint(cert tls.Certificate) string {
+	sum := sha256.Sum256(cert.Certificate[0])
+	return hex.EncodeToString(sum[:])
+}
+
+// clientCertificate describes the certificate the client presented, or
+// returns nil for plain HTTP requests and TLS requests without one
+func clientCertificate(ctx *fasthttp.RequestCtx) *configs.ClientCertificate {
+	state := ctx.TLSConnectionState()
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	leaf := state.PeerCertificates[0]
+	cert := &configs.ClientCertificate{
+		Subject:    leaf.Subject.String(),
+		CommonName: leaf.Subject.CommonName,
+	}
+	cert.SANs = append(cert.SANs, leaf.DNSNames...)
+	cert.SANs = append(cert.SANs, leaf.EmailAddresses...)
+	for _, ip := range leaf.IPAddresses {
+		cert.SANs = append(cert.SANs, ip.String())
+	}
+	for _, uri := range leaf.URIs {
+		cert.SANs = append(cert.SANs, uri.String())
+	}
+
+	return cert
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+)
+
+// testCert is a certificate and key created for a test
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	tls  tls.Certificate
+}
+
+// newTestCert creates a certificate for subject signed by parent, or a
+// self-signed CA when parent is nil
+func newTestCert(t *testing.T, subject pkix.Name, dnsNames []string, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      subject,
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCert{cert: cert, key: key, tls: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}}
+}
+
+// writePEM writes the certificate to dir/name and returns its path
+func (c *testCert) writePEM(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGenerateSelfSignedCert(t *testing.T) {
+	cert, err := generateSelfSignedCert([]string{"mock.internal", "10.1.2.3"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, host := range []string{"localhost", "127.0.0.1", "mock.internal", "10.1.2.3"} {
+		if err := cert.Leaf.VerifyHostname(host); err != nil {
+			t.Errorf("Expected certificate to be valid for %s: %v", host, err)
+		}
+	}
+	if len(certificateFingerprint(cert)) != 64 {
+		t.Errorf("Expected a hex SHA-256 fingerprint, got %q", certificateFingerprint(cert))
+	}
+}
+
+func TestNewTLSConfig_Errors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config configs.TLSConfig
+	}{
+		{"missing cert file", configs.TLSConfig{CertFile: filepath.Join(dir, "missing.pem"), KeyFile: filepath.Join(dir, "missing.key")}},
+		{"missing client CA", configs.TLSConfig{SelfSigned: true, ClientAuth: "require_and_verify", ClientCAFile: filepath.Join(dir, "missing.pem")}},
+		{"client CA without certificates", configs.TLSConfig{SelfSigned: true, ClientAuth: "require_and_verify", ClientCAFile: notPEM}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newTLSConfig(tt.config); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestServer_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, pkix.Name{CommonName: "Test CA"}, nil, nil)
+	partner := newTestCert(t, pkix.Name{CommonName: "partner-a", Organization: []string{"Acme"}}, []string{"partner-a.example.com"}, ca)
+	other := newTestCert(t, pkix.Name{CommonName: "partner-b"}, nil, ca)
+	stranger := newTestCert(t, pkix.Name{CommonName: "stranger"}, nil, nil)
+
+	config := &configs.ServerConfig{
+		TLS: configs.TLSConfig{
+			SelfSigned:   true,
+			ClientAuth:   configs.ClientAuthRequireAndVerify,
+			ClientCAFile: ca.writePEM(t, dir, "ca.pem"),
+		},
+		Routes: []configs.Route{
+			{
+				Path:         "/partner",
+				Method:       "GET",
+				ResponseBody: "unknown partner",
+				Conditions: []configs.RouteCondition{
+					{
+						ClientCert:   &configs.ClientCertMatch{SAN: "partner-a.example.com"},
+						ResponseBody: "hello partner a",
+					},
+				},
+			},
+			{Path: "/dump", Method: "GET", ResponseDump: true},
+		},
+	}
+
+	tlsConfig, err := newTLSConfig(config.TLS)
+	if err != nil {
+		t.Fatalf("Failed to build TLS config: %v", err)
+	}
+
+	server := &Server{config: config}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+	httpServer := &fasthttp.Server{
+		Handler:   server.router.Handler,
+		TLSConfig: tlsConfig,
+		Logger:    log.New(io.Discard, "", 0), // Rejected handshakes are expected
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go httpServer.ServeTLS(ln, "", "")
+	defer httpServer.Shutdown()
+
+	// client connects presenting cert, trusting the generated server certificate
+	client := func(cert *testCert) *http.Client {
+		roots := x509.NewCertPool()
+		roots.AddCert(tlsConfig.Certificates[0].Leaf)
+		clientTLS := &tls.Config{RootCAs: roots}
+		if cert != nil {
+			clientTLS.Certificates = []tls.Certificate{cert.tls}
+		}
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS}, Timeout: 5 * time.Second}
+	}
+	get := func(t *testing.T, cert *testCert, path string) (string, error) {
+		t.Helper()
+		resp, err := client(cert).Get("https://localhost:" + portOf(ln) + path)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	t.Run("condition matches SAN", func(t *testing.T) {
+		body, err := get(t, partner, "/partner")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if body != "hello partner a" {
+			t.Errorf("Expected condition response, got %q", body)
+		}
+
+		body, err = get(t, other, "/partner")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if body != "unknown partner" {
+			t.Errorf("Expected default response for another partner, got %q", body)
+		}
+	})
+
+	t.Run("dump includes client certificate", func(t *testing.T) {
+		body, err := get(t, partner, "/dump")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		var dump RequestDump
+		if err := json.Unmarshal([]byte(body), &dump); err != nil {
+			t.Fatalf("Failed to parse dump %q: %v", body, err)
+		}
+		if dump.ClientCert == nil {
+			t.Fatal("Expected client_cert in dump")
+		}
+		if dump.ClientCert.CommonName != "partner-a" || dump.ClientCert.Subject != "CN=partner-a,O=Acme" {
+			t.Errorf("Unexpected client certificate subject: %+v", dump.ClientCert)
+		}
+		if len(dump.ClientCert.SANs) != 1 || dump.ClientCert.SANs[0] != "partner-a.example.com" {
+			t.Errorf("Expected SAN partner-a.example.com, got %v", dump.ClientCert.SANs)
+		}
+		if dump.TLS == nil || dump.TLS.Version != "TLS 1.3" || dump.TLS.ServerName != "localhost" {
+			t.Errorf("Expected TLS 1.3 details for localhost, got %+v", dump.TLS)
+		}
+	})
+
+	t.Run("rejects clients without a trusted certificate", func(t *testing.T) {
+		if _, err := get(t, nil, "/partner"); err == nil {
+			t.Error("Expected the handshake to fail without a client certificate")
+		}
+		if _, err := get(t, stranger, "/partner"); err == nil {
+			t.Error("Expected the handshake to fail with an untrusted client certificate")
+		}
+	})
+}
+
+// portOf returns the port a listener is bound to
+func portOf(ln net.Listener) string {
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	return port
+}
+
+func TestNewHTTPServer(t *testing.T) {
+	handler := newReloadableHandler(&Server{config: &configs.ServerConfig{}})
+
+	plain, err := newHTTPServer(handler, configs.Listener{Name: "plain", Address: ":9001"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if plain.TLSConfig != nil {
+		t.Error("Expected no TLS config for a plain listener")
+	}
+
+	secure, err := newHTTPServer(handler, configs.Listener{
+		Name:    "secure",
+		Address: ":9443",
+		TLS:     configs.TLSConfig{SelfSigned: true},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if secure.TLSConfig == nil || len(secure.TLSConfig.Certificates) != 1 {
+		t.Error("Expected a TLS config with the generated certificate")
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the instrumentation that creates echo2's spans
+const tracerName = "github.com/yirwanditiket/echo2"
+
+// tracing creates a server span for every route request and exports it over
+// OTLP. Like metrics, a single instance outlives config refreshes.
+type tracing struct {
+	provider    *sdktrace.TracerProvider
+	tracer      trace.Tracer
+	propagator  propagation.TextMapPropagator // W3C traceparent and tracestate
+	echoHeaders bool                          // Whether to return the span context in response headers
+}
+
+// newTracing creates the tracer provider exporting spans with exporter
+func newTracing(config configs.TracingConfig, exporter sdktrace.SpanExporter) *tracing {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", config.ServiceName))),
+	)
+
+	return &tracing{
+		provider:    provider,
+		tracer:      provider.Tracer(tracerName),
+		propagator:  propagation.TraceContext{},
+		echoHeaders: config.EchoHeaders,
+	}
+}
+
+// newOTLPExporter creates the OTLP/HTTP exporter for the configured endpoint.
+// No connection is made until the first batch of spans is sent.
+func newOTLPExporter(config configs.TracingConfig) (sdktrace.SpanExporter, error) {
+	var opts []otlptracehttp.Option
+	if strings.Contains(config.Endpoint, "://") {
+		opts = append(opts, otlptracehttp.WithEndpointURL(config.Endpoint))
+	} else {
+		opts = append(opts, otlptracehttp.WithEndpoint(config.Endpoint))
+	}
+	if config.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(config.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(config.Headers))
+	}
+
+	return otlptracehttp.New(context.Background(), opts...)
+}
+
+// start begins the server span for a request to route, continuing the trace
+// from the request's traceparent and tracestate headers when present
+func (t *tracing) start(ctx *fasthttp.RequestCtx, route configs.Route) trace.Span {
+	method := strings.ToUpper(route.GetMethod())
+	parent := t.propagator.Extract(context.Background(), requestHeaderCarrier{&ctx.Request.Header})
+
+	spanCtx, span := t.tracer.Start(parent, method+" "+route.Path,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", string(ctx.Method())),
+			attribute.String("http.route", route.Path),
+			attribute.String("url.path", string(ctx.Path())),
+			attribute.String("client.address", getRequestInfo(ctx).clientIP.String()),
+			attribute.String("user_agent.original", string(ctx.UserAgent())),
+		),
+	)
+	if id := getRequestInfo(ctx).requestID; id != "" {
+		span.SetAttributes(attribute.String("echo2.request_id", id))
+	}
+	if source := route.Source(); source != "" {
+		span.SetAttributes(attribute.String("echo2.route.source", source))
+	}
+
+	if t.echoHeaders {
+		t.propagator.Inject(spanCtx, responseHeaderCarrier{&ctx.Response.Header})
+	}
+
+	return span
+}
+
+// finish records how the request was handled on span and ends it
+func (t *tracing) finish(span trace.Span, info *requestInfo, status int) {
+	condition := "default"
+	if info.condition >= 0 {
+		condition = strconv.Itoa(info.condition)
+	}
+
+	span.SetAttributes(
+		attribute.Int("http.response.status_code", status),
+		attribute.String("echo2.condition", condition),
+	)
+	if info.delay > 0 {
+		span.SetAttributes(
+			attribute.String("echo2.delay", info.delay.String()),
+			attribute.Bool("echo2.delay.cancelled", info.cancelled),
+		)
+	}
+	if status >= fasthttp.StatusInternalServerError {
+		span.SetStatus(codes.Error, fasthttp.StatusMessage(status))
+	}
+
+	span.End()
+}
+
+// shutdown exports any buffered spans and stops the exporter
+func (t *tracing) shutdown(ctx context.Context) error {
+	return t.provider.Shutdown(ctx)
+}
+
+// requestHeaderCarrier adapts fasthttp request headers to a TextMapCarrier
+type requestHeaderCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+func (c requestHeaderCarrier) Get(key string) string { return string(c.header.Peek(key)) }
+
+func (c requestHeaderCarrier) Set(key, value string) { c.header.Set(key, value) }
+
+func (c requestHeaderCarrier) Keys() []string {
+	var keys []string
+	c.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+// responseHeaderCarrier adapts fasthttp response headers to a TextMapCarrier
+type responseHeaderCarrier struct {
+	header *fasthttp.ResponseHeader
+}
+
+func (c responseHeaderCarrier) Get(key string) string { return string(c.header.Peek(key)) }
+
+func (c responseHeaderCarrier) Set(key, value string) { c.header.Set(key, value) }
+
+func (c responseHeaderCarrier) Keys() []string {
+	var keys []string
+	c.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
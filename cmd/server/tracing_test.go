@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"github.com/yirwanditiket/echo2/configs"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestServer_Tracing(t *testing.T) {
+	config := &configs.ServerConfig{
+		Routes: []configs.Route{
+			{
+				Path:         "/api/{id}",
+				Method:       "GET",
+				ResponseBody: "default",
+				Conditions: []configs.RouteCondition{
+					{
+						HeaderMatch:    map[string]string{"X-Test": "broken"},
+						ResponseStatus: 503,
+					},
+				},
+			},
+		},
+	}
+	tracingConfig := configs.TracingConfig{ServiceName: "echo2", SampleRatio: 1, EchoHeaders: true}
+
+	exporter := tracetest.NewInMemoryExporter()
+	server := &Server{config: config, tracing: newTracing(tracingConfig, exporter)}
+	if err := server.initializeRouter(); err != nil {
+		t.Fatalf("initializeRouter() error = %v", err)
+	}
+
+	request := func(uri string, headers map[string]string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(uri)
+		ctx.Request.Header.SetMethod("GET")
+		for key, value := range headers {
+			ctx.Request.Header.Set(key, value)
+		}
+		server.router.Handler(ctx)
+		return ctx
+	}
+	spans := func() tracetest.SpanStubs {
+		if err := server.tracing.provider.ForceFlush(context.Background()); err != nil {
+			t.Fatalf("Failed to flush spans: %v", err)
+		}
+		defer exporter.Reset()
+		return exporter.GetSpans()
+	}
+
+	t.Run("continues incoming trace", func(t *testing.T) {
+		traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+		ctx := request("/api/1?delay=1ms", map[string]string{
+			"traceparent": traceparent,
+			"tracestate":  "vendor=value",
+		})
+
+		got := spans()
+		if len(got) != 1 {
+			t.Fatalf("Expected 1 span, got %d", len(got))
+		}
+		span := got[0]
+
+		if span.Name != "GET /api/{id}" {
+			t.Errorf("Expected span name 'GET /api/{id}', got %q", span.Name)
+		}
+		if span.SpanKind != trace.SpanKindServer {
+			t.Errorf("Expected a server span, got %v", span.SpanKind)
+		}
+		if span.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("Expected the incoming trace ID, got %s", span.SpanContext.TraceID())
+		}
+		if span.Parent.SpanID().String() != "00f067aa0ba902b7" {
+			t.Errorf("Expected the incoming span as parent, got %s", span.Parent.SpanID())
+		}
+		if span.SpanContext.TraceState().Get("vendor") != "value" {
+			t.Errorf("Expected tracestate to be kept, got %q", span.SpanContext.TraceState().String())
+		}
+
+		attributes := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes {
+			attributes[kv.Key] = kv.Value
+		}
+		expected := map[attribute.Key]string{
+			"http.route":                "/api/{id}",
+			"url.path":                  "/api/1",
+			"http.response.status_code": "200",
+			"echo2.condition":           "default",
+			"echo2.delay":               "1ms",
+		}
+		for key, value := range expected {
+			if got := attributes[key].Emit(); got != value {
+				t.Errorf("Expected attribute %s=%q, got %q", key, value, got)
+			}
+		}
+
+		// The response carries this span's context so the caller can find it
+		echoed := string(ctx.Response.Header.Peek("traceparent"))
+		if !strings.HasPrefix(echoed, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+span.SpanContext.SpanID().String()) {
+			t.Errorf("Expected traceparent response header for the server span, got %q", echoed)
+		}
+		if got := string(ctx.Response.Header.Peek("tracestate")); got != "vendor=value" {
+			t.Errorf("Expected tracestate response header 'vendor=value', got %q", got)
+		}
+	})
+
+	t.Run("records condition and error status", func(t *testing.T) {
+		request("/api/2", map[string]string{"X-Test": "broken"})
+
+		got := spans()
+		if len(got) != 1 {
+			t.Fatalf("Expected 1 span, got %d", len(got))
+		}
+		span := got[0]
+
+		if span.Parent.IsValid() {
+			t.Errorf("Expected a root span without traceparent, got parent %s", span.Parent.SpanID())
+		}
+		if span.Status.Description != "Service Unavailable" {
+			t.Errorf("Expected error status for 503, got %+v", span.Status)
+		}
+		for _, kv := range span.Attributes {
+			if kv.Key == "echo2.condition" && kv.Value.AsString() != "0" {
+				t.Errorf("Expected echo2.condition=0, got %q", kv.Value.AsString())
+			}
+		}
+	})
+
+	t.Run("headers not echoed by default", func(t *testing.T) {
+		server.tracing.echoHeaders = false
+		ctx := request("/api/3", nil)
+		spans()
+
+		if got := ctx.Response.Header.Peek("traceparent"); len(got) != 0 {
+			t.Errorf("Expected no traceparent response header, got %q", got)
+		}
+	})
+}
+
+func TestNewOTLPExporter(t *testing.T) {
+	endpoints := []string{"localhost:4318", "http://collector:4318/v1/traces"}
+	for _, endpoint := range endpoints {
+		t.Run(endpoint, func(t *testing.T) {
+			exporter, err := newOTLPExporter(configs.TracingConfig{Endpoint: endpoint, Insecure: true})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err := exporter.Shutdown(context.Background()); err != nil {
+				t.Errorf("Expected clean shutdown, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+// noopHandler is registered on probe routers that only check route paths
+func noopHandler(*fasthttp.RequestCtx) {}
+
+// detectRouteConflicts registers every route on a throwaway router, the same
+// way the server does at startup, and turns the router's registration panics
+// into errors. Only routes for host served on listener share a router. When
+// a route conflicts with an earlier one both indexes are reported together
+// with the router's reason.
+func detectRouteConflicts(routes []Route, listener, host string) error {
+	probe := router.New()
+
+	for i, route := range routes {
+		if !route.ServedOn(listener) || !strings.EqualFold(route.Host, host) {
+			continue
+		}
+		method := strings.ToUpper(route.GetMethod())
+
+		reason := tryRegister(probe, method, route.Path)
+		if reason == "" {
+			continue
+		}
+
+		// Find the earlier route responsible by replaying the pairs one at a time
+		if msg := tryRegister(router.New(), method, route.Path); msg != "" {
+			return fmt.Errorf("%s: invalid path '%s': %s", route.describe(i), route.Path, msg)
+		}
+		for j := 0; j < i; j++ {
+			if strings.ToUpper(routes[j].GetMethod()) != method || !routes[j].ServedOn(listener) || !strings.EqualFold(routes[j].Host, host) {
+				continue
+			}
+			pair := router.New()
+			pair.Handle(method, routes[j].Path, noopHandler)
+			if msg := tryRegister(pair, method, route.Path); msg != "" {
+				return fmt.Errorf("%s: %s %s conflicts with %s %s from %s: %s",
+					route.describe(i), method, route.Path, method, routes[j].Path, routes[j].describe(j), msg)
+			}
+		}
+
+		return fmt.Errorf("%s: %s %s conflicts with earlier routes: %s", route.describe(i), method, route.Path, reason)
+	}
+
+	return nil
+}
+
+// detectReservedConflict reports a host-less route served on listener that
+// collides with a built-in endpoint, such as the metrics path, named by
+// setting in errors
+func detectReservedConflict(routes []Route, listener, method, path, setting string) error {
+	if msg := tryRegister(router.New(), method, path); msg != "" {
+		return fmt.Errorf("%s: invalid path '%s': %s", setting, path, msg)
+	}
+
+	for i, route := range routes {
+		if strings.ToUpper(route.GetMethod()) != method || !route.ServedOn(listener) || route.Host != "" {
+			continue
+		}
+		pair := router.New()
+		pair.Handle(method, path, noopHandler)
+		if msg := tryRegister(pair, method, route.Path); msg != "" {
+			return fmt.Errorf("%s: %s %s conflicts with %s %s: %s",
+				route.describe(i), method, route.Path, setting, path, msg)
+		}
+	}
+
+	return nil
+}
+
+// tryRegister adds a path to r and returns the panic message raised by the
+// router, or an empty string when registration succeeded.
+func tryRegister(r *router.Router, method, path string) (reason string) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			reason = fmt.Sprint(recovered)
+		}
+	}()
+
+	r.Handle(method, path, noopHandler)
+	return ""
+}
@@ -0,0 +1,96 @@
+package configs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectRouteConflicts(t *testing.T) {
+	tests := []struct {
+		name        string
+		routes      []Route
+		expectedErr string
+	}{
+		{
+			name: "no conflicts",
+			routes: []Route{
+				{Path: "/api/users"},
+				{Path: "/api/{anything}"},
+				{Path: "/api/users", Method: "POST"},
+				{Path: "/files/{filepath:*}"},
+			},
+		},
+		{
+			name: "duplicate method and path",
+			routes: []Route{
+				{Path: "/health"},
+				{Path: "/other"},
+				{Path: "/health", Method: "GET"},
+			},
+			expectedErr: "route 2: GET /health conflicts with GET /health from route 0: a handler is already registered",
+		},
+		{
+			name: "same path with different methods",
+			routes: []Route{
+				{Path: "/health", Method: "GET"},
+				{Path: "/health", Method: "HEAD"},
+			},
+		},
+		{
+			name: "wildcards at the same segment",
+			routes: []Route{
+				{Path: "/api/{anything}"},
+				{Path: "/api/{id:[0-9]+}"},
+			},
+			expectedErr: "route 1: GET /api/{id:[0-9]+} conflicts with GET /api/{anything} from route 0: '{id:[0-9]+}' in new path",
+		},
+		{
+			name: "path without leading slash",
+			routes: []Route{
+				{Path: "health"},
+			},
+			expectedErr: "route 0: invalid path 'health'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := detectRouteConflicts(tt.routes, "", "")
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected error containing %q, got nil", tt.expectedErr)
+			}
+			if !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("Expected error containing %q, got %q", tt.expectedErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestDetectReservedConflict(t *testing.T) {
+	routes := []Route{
+		{Path: "/health"},
+		{Path: "/metrics", Method: "POST"},
+		{Path: "/{anything}"},
+	}
+
+	if err := detectReservedConflict(routes[:2], "", "GET", "/metrics", "metrics.path"); err != nil {
+		t.Errorf("Expected no conflict with a different method, got %v", err)
+	}
+
+	err := detectReservedConflict(routes, "", "GET", "/{page}", "metrics.path")
+	expected := "route 2: GET /{anything} conflicts with metrics.path /{page}"
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("Expected error containing %q, got %v", expected, err)
+	}
+
+	err = detectReservedConflict(routes, "", "GET", "metrics", "metrics.path")
+	if err == nil || !strings.Contains(err.Error(), "metrics.path: invalid path 'metrics'") {
+		t.Errorf("Expected invalid path error, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package configs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Defaults declared with `default:"..."` struct tags. The getters fall back to
+// these so configs built in code behave like loaded ones.
+var (
+	defaultLogLevel       = defaultTag(ServerConfig{}, "LogLevel")
+	defaultLogFormat      = defaultTag(ServerConfig{}, "LogFormat")
+	defaultMethod         = defaultTag(Route{}, "Method")
+	defaultResponseStatus = mustAtoi(defaultTag(Route{}, "ResponseStatus"))
+
+	defaultConditionResponseStatus = mustAtoi(defaultTag(RouteCondition{}, "ResponseStatus"))
+	defaultRequestIDHeader         = defaultTag(RequestIDConfig{}, "Header")
+	defaultClientAuth              = defaultTag(TLSConfig{}, "ClientAuth")
+	defaultControlsMaxSize         = defaultTag(ControlsConfig{}, "MaxSize")
+)
+
+// applyDefaults fills every zero-valued field that declares a default tag,
+// recursing into nested structs, slices and pointers
+func applyDefaults(v any) error {
+	return applyDefaultsValue(reflect.ValueOf(v), "")
+}
+
+// applyDefaultsValue walks v and sets defaults on its settable fields
+func applyDefaultsValue(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return applyDefaultsValue(v.Elem(), path)
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := applyDefaultsValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldPath := joinPath(path, field.Name)
+			if tag, ok := field.Tag.Lookup("default"); ok && v.Field(i).IsZero() {
+				if err := setFromString(v.Field(i), tag); err != nil {
+					return fmt.Errorf("%s: invalid default %q: %w", fieldPath, tag, err)
+				}
+			}
+			if err := applyDefaultsValue(v.Field(i), fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// setFromString parses s into v according to v's kind
+func setFromString(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("defaults are not supported for %s fields", v.Type())
+	}
+
+	return nil
+}
+
+// defaultTag returns the default tag of the named field of v's struct type.
+// It panics when the field does not exist, which only a typo in this package
+// can cause.
+func defaultTag(v any, fieldName string) string {
+	field, ok := reflect.TypeOf(v).FieldByName(fieldName)
+	if !ok {
+		panic(fmt.Sprintf("configs: %T has no field %s", v, fieldName))
+	}
+	return field.Tag.Get("default")
+}
+
+// mustAtoi parses an integer default tag, panicking on a malformed tag
+func mustAtoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		panic(fmt.Sprintf("configs: invalid integer default %q", s))
+	}
+	return n
+}
@@ -0,0 +1,77 @@
+package configs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyDefaults(t *testing.T) {
+	t.Run("fills zero values from tags", func(t *testing.T) {
+		config := &ServerConfig{
+			Routes: []Route{
+				{
+					Path:       "/test",
+					Conditions: []RouteCondition{{}},
+				},
+				{
+					Path:           "/custom",
+					Method:         "POST",
+					ResponseStatus: 201,
+				},
+			},
+		}
+
+		if err := applyDefaults(config); err != nil {
+			t.Fatalf("applyDefaults() error = %v", err)
+		}
+
+		if config.Address != ":12330" {
+			t.Errorf("Expected default address :12330, got %s", config.Address)
+		}
+		if config.LogLevel != "info" {
+			t.Errorf("Expected default log level info, got %s", config.LogLevel)
+		}
+		if config.Routes[0].Method != "GET" {
+			t.Errorf("Expected default method GET, got %s", config.Routes[0].Method)
+		}
+		if config.Routes[0].ResponseStatus != 200 {
+			t.Errorf("Expected default status 200, got %d", config.Routes[0].ResponseStatus)
+		}
+		if config.Routes[0].Conditions[0].ResponseStatus != 200 {
+			t.Errorf("Expected default condition status 200, got %d", config.Routes[0].Conditions[0].ResponseStatus)
+		}
+		if config.Routes[1].Method != "POST" || config.Routes[1].ResponseStatus != 201 {
+			t.Errorf("Expected explicit values to be kept, got %s %d", config.Routes[1].Method, config.Routes[1].ResponseStatus)
+		}
+	})
+
+	t.Run("supported kinds", func(t *testing.T) {
+		var v struct {
+			Name    string        `default:"name"`
+			Enabled bool          `default:"true"`
+			Count   int           `default:"3"`
+			Size    uint          `default:"4"`
+			Ratio   float64       `default:"0.5"`
+			Timeout time.Duration `default:"1s"`
+		}
+
+		if err := applyDefaults(&v); err != nil {
+			t.Fatalf("applyDefaults() error = %v", err)
+		}
+		if v.Name != "name" || !v.Enabled || v.Count != 3 || v.Size != 4 || v.Ratio != 0.5 || v.Timeout != time.Second {
+			t.Errorf("Unexpected defaults applied: %+v", v)
+		}
+	})
+
+	t.Run("invalid tag", func(t *testing.T) {
+		var v struct {
+			Count int `default:"many"`
+		}
+
+		err := applyDefaults(&v)
+		if err == nil || !strings.Contains(err.Error(), `Count: invalid default "many"`) {
+			t.Errorf("Expected invalid default error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package configs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config file formats understood by LoadConfig
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatTOML = "toml"
+)
+
+// formatExtensions maps file extensions to the format they contain
+var formatExtensions = map[string]string{
+	".yaml": FormatYAML,
+	".yml":  FormatYAML,
+	".json": FormatJSON,
+	".toml": FormatTOML,
+}
+
+// tomlLinePattern matches a TOML table header ([table] or [[array]]) or a
+// "key = value" assignment, neither of which is a valid config in YAML
+var tomlLinePattern = regexp.MustCompile(`^(\[\[?\s*[A-Za-z0-9_."' -]+\s*\]\]?|[A-Za-z0-9_"'-]+\s*=)`)
+
+// detectFormat picks the format of a config file from its extension, or
+// from its content when the extension is unknown
+func detectFormat(path string, data []byte) string {
+	if format, ok := formatExtensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return format
+	}
+
+	line := firstSignificantLine(data)
+	switch {
+	case strings.HasPrefix(line, "{"):
+		return FormatJSON
+	case tomlLinePattern.MatchString(line):
+		return FormatTOML
+	}
+
+	return FormatYAML
+}
+
+// firstSignificantLine returns the first line that is neither blank nor a
+// comment, with surrounding whitespace removed
+func firstSignificantLine(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
+
+// parseDocument parses a config file of the given format into a YAML node
+// tree, so every format shares the same strict checks and decoding. YAML and
+// JSON nodes keep their line and column. The TOML decoder does not expose
+// positions, so only TOML syntax errors have a line; other errors name the
+// field.
+func parseDocument(data []byte, format string) (*yaml.Node, error) {
+	var document yaml.Node
+
+	switch format {
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, err
+		}
+	case FormatJSON:
+		return parseJSON(data)
+	case FormatTOML:
+		var generic map[string]any
+		if _, err := toml.NewDecoder(bytes.NewReader(data)).Decode(&generic); err != nil {
+			return nil, err
+		}
+		if err := document.Encode(generic); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", format)
+	}
+
+	return &document, nil
+}
+
+// jsonParser builds a YAML node tree from the tokens of a JSON document. The
+// YAML parser cannot read every JSON document: it rejects surrogate pair
+// escapes such as \ud83d\ude00 and duplicate keys, which JSON allows.
+type jsonParser struct {
+	data    []byte
+	decoder *json.Decoder
+}
+
+// parseJSON parses a JSON document into a YAML node tree whose nodes keep
+// their line and column, in the order of the document. A repeated key keeps
+// its last value, as with encoding/json.
+func parseJSON(data []byte) (*yaml.Node, error) {
+	// An empty document decodes to the zero config, as with YAML
+	if len(bytes.TrimSpace(data)) == 0 {
+		return &yaml.Node{}, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	p := &jsonParser{data: data, decoder: decoder}
+
+	root, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		line, column := p.position(p.offset())
+		return nil, fmt.Errorf("json: line %d, column %d: unexpected data after the document", line, column)
+	}
+
+	return &yaml.Node{Kind: yaml.DocumentNode, Line: 1, Column: 1, Content: []*yaml.Node{root}}, nil
+}
+
+// value reads the next JSON value and returns its node
+func (p *jsonParser) value() (*yaml.Node, error) {
+	line, column := p.position(p.offset())
+	token, err := p.token()
+	if err != nil {
+		return nil, err
+	}
+	node := &yaml.Node{Kind: yaml.ScalarNode, Line: line, Column: column}
+
+	switch token := token.(type) {
+	case json.Delim:
+		switch token {
+		case '{':
+			node.Kind, node.Tag = yaml.MappingNode, "!!map"
+			keys := make(map[string]int)
+			for p.decoder.More() {
+				line, column := p.position(p.offset())
+				key, err := p.token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				if i, ok := keys[key.(string)]; ok {
+					node.Content[i+1] = value
+					continue
+				}
+				keys[key.(string)] = len(node.Content)
+				keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string), Line: line, Column: column}
+				node.Content = append(node.Content, keyNode, value)
+			}
+		case '[':
+			node.Kind, node.Tag = yaml.SequenceNode, "!!seq"
+			for p.decoder.More() {
+				value, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, value)
+			}
+		}
+		// The closing delimiter
+		if _, err := p.token(); err != nil {
+			return nil, err
+		}
+	case string:
+		node.Tag, node.Value, node.Style = "!!str", token, yaml.DoubleQuotedStyle
+	case json.Number:
+		node.Tag, node.Value = "!!int", token.String()
+		if strings.ContainsAny(node.Value, ".eE") {
+			node.Tag = "!!float"
+		}
+	case bool:
+		node.Tag, node.Value = "!!bool", strconv.FormatBool(token)
+	case nil:
+		node.Tag, node.Value = "!!null", "null"
+	}
+
+	return node, nil
+}
+
+// token reads the next token, adding the position to syntax errors
+func (p *jsonParser) token() (json.Token, error) {
+	token, err := p.decoder.Token()
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, column := p.position(int(syntaxErr.Offset) - 1)
+		return nil, fmt.Errorf("json: line %d, column %d: %w", line, column, err)
+	}
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("json: unexpected end of document")
+	}
+	return token, err
+}
+
+// offset returns the offset of the next token, skipping the whitespace and
+// separators the decoder has not consumed yet
+func (p *jsonParser) offset() int {
+	offset := int(p.decoder.InputOffset())
+	for offset < len(p.data) && strings.IndexByte(" \t\r\n,:", p.data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// position converts a byte offset into a 1-based line and column
+func (p *jsonParser) position(offset int) (int, int) {
+	offset = min(offset, len(p.data))
+	before := p.data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	return line, utf8.RuneCount(before[lineStart:]) + 1
+}
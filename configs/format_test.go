@@ -0,0 +1,240 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		expected string
+	}{
+		{name: "yaml extension", path: "config.yaml", content: `{"routes": []}`, expected: FormatYAML},
+		{name: "yml extension", path: "config.YML", expected: FormatYAML},
+		{name: "json extension", path: "config.json", expected: FormatJSON},
+		{name: "toml extension", path: "config.toml", expected: FormatTOML},
+		{name: "json content", path: "config", content: "\n  {\"routes\": []}", expected: FormatJSON},
+		{name: "toml table content", path: "config.conf", content: "# mocks\n[[routes]]\npath = \"/a\"\n", expected: FormatTOML},
+		{name: "toml key content", path: "-", content: "address = \":8080\"\n", expected: FormatTOML},
+		{name: "yaml content", path: "config", content: "# mocks\naddress: \":8080\"\n", expected: FormatYAML},
+		{name: "empty content", path: "config", content: "", expected: FormatYAML},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectFormat(tt.path, []byte(tt.content)); got != tt.expected {
+				t.Errorf("detectFormat() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Formats(t *testing.T) {
+	tests := []struct {
+		name        string
+		fileName    string
+		content     string
+		expectedErr string
+	}{
+		{
+			name:     "json",
+			fileName: "config.json",
+			content: `{
+	"address": ":8080",
+	"routes": [
+		{"path": "/health", "response_body": "OK"},
+		{
+			"path": "/api/users",
+			"method": "POST",
+			"response_status": 201,
+			"response_header": {"Content-Type": "application/json", "Vary": ["Accept", "Origin"]},
+			"conditions": [{"header_match": {"X-Test": "1"}, "response_body": "matched"}]
+		}
+	]
+}
+`,
+		},
+		{
+			name:     "toml",
+			fileName: "config.toml",
+			content: `address = ":8080"
+
+[[routes]]
+path = "/health"
+response_body = "OK"
+
+[[routes]]
+path = "/api/users"
+method = "POST"
+response_status = 201
+response_header = { "Content-Type" = "application/json", "Vary" = ["Accept", "Origin"] }
+
+[[routes.conditions]]
+header_match = { "X-Test" = "1" }
+response_body = "matched"
+`,
+		},
+		{
+			name:     "json detected from content",
+			fileName: "config",
+			content:  `{"address": ":8080", "routes": [{"path": "/health", "response_body": "OK"}, {"path": "/api/users", "method": "POST", "response_status": 201, "response_header": {"Content-Type": "application/json", "Vary": ["Accept", "Origin"]}, "conditions": [{"header_match": {"X-Test": "1"}, "response_body": "matched"}]}]}`,
+		},
+		{
+			name:     "json unknown field keeps its position",
+			fileName: "config.json",
+			content: `{
+	"routes": [
+		{"path": "/health", "respone_body": "OK"}
+	]
+}
+`,
+			expectedErr: `line 3, column 23: unknown field "respone_body" in routes[0] (did you mean "response_body"?)`,
+		},
+		{
+			name:     "toml unknown field",
+			fileName: "config.toml",
+			content: `[[routes]]
+path = "/health"
+respone_body = "OK"
+`,
+			expectedErr: `unknown field "respone_body" in routes[0] (did you mean "response_body"?)`,
+		},
+		{
+			name:     "toml mistyped field",
+			fileName: "config.toml",
+			content: `[[routes]]
+path = "/health"
+response_status = "ok"
+`,
+			expectedErr: `routes[0].response_status: expected an integer, got !!str "ok"`,
+		},
+		{
+			name:     "toml mistyped header value",
+			fileName: "config.toml",
+			content: `[[routes]]
+path = "/health"
+response_header = { "Vary" = [{ "name" = "Accept" }] }
+`,
+			expectedErr: `routes[0].response_header.Vary[0]: expected a string, got a mapping`,
+		},
+		{
+			name:     "toml syntax error",
+			fileName: "config.toml",
+			content: `[[routes]
+path = "/health"
+`,
+			expectedErr: "toml: line",
+		},
+		{
+			name:     "json surrogate pair escape",
+			fileName: "config.json",
+			content:  `{"address": ":8080", "routes": [{"path": "/health", "response_body": "\ud83d\ude00"}, {"path": "/api/users", "method": "POST", "response_status": 201, "response_header": {"Content-Type": "application/json", "Vary": ["Accept", "Origin"]}, "conditions": [{"header_match": {"X-Test": "1"}, "response_body": "matched"}]}]}`,
+		},
+		{
+			name:     "json duplicate key keeps the last value",
+			fileName: "config.json",
+			content:  `{"address": ":9090", "address": ":8080", "routes": [{"path": "/health"}, {"path": "/api/users", "method": "GET", "method": "POST", "response_status": 201, "response_header": {"Content-Type": "application/json", "Vary": ["Accept", "Origin"]}, "conditions": [{"header_match": {"X-Test": "1"}, "response_body": "matched"}]}]}`,
+		},
+		{
+			name:     "json mistyped field keeps its position",
+			fileName: "config.json",
+			content: `{
+  "routes": [{"path": "/health", "response_status": "ok"}]
+}
+`,
+			expectedErr: `line 2, column 53: routes[0].response_status: expected an integer, got !!str "ok"`,
+		},
+		{
+			name:        "json syntax error",
+			fileName:    "config.json",
+			content:     "{\n  \"routes\": [}\n",
+			expectedErr: "json: line 2, column 14: invalid character '}'",
+		},
+		{
+			name:        "json truncated",
+			fileName:    "config.json",
+			content:     `{"routes": [{"path": "/health"}`,
+			expectedErr: "json: line 1, column 31: unexpected end of JSON input",
+		},
+		{
+			name:     "toml nested unknown field",
+			fileName: "config.toml",
+			content: `address = ":8080"
+
+[[routes]]
+path = "/a"
+
+[[routes]]
+path = "/b"
+
+  [[routes.conditions]]
+  response_body = """
+header_macth = "not a key"
+"""
+  header_macth = { "X-Test" = "1" }
+`,
+			expectedErr: `unknown field "header_macth" in routes[1].conditions[0] (did you mean "header_match"?)`,
+		},
+		{
+			name:        "toml validation error names the file",
+			fileName:    "config.toml",
+			content:     "[[routes]]\npath = \"/a\"\n\n[[routes]]\npath = \"/b\"\nmethod = \"FETCH\"\n",
+			expectedErr: "config.toml): invalid HTTP method 'FETCH'",
+		},
+		{
+			name:        "json validation error",
+			fileName:    "config.json",
+			content:     `{"routes": [{"path": "/a", "method": "FETCH"}]}`,
+			expectedErr: "invalid HTTP method 'FETCH'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), tt.fileName)
+			if err := os.WriteFile(configFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			config, err := LoadConfig(configFile)
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+
+			if config.Address != ":8080" {
+				t.Errorf("Expected address :8080, got %s", config.Address)
+			}
+			if len(config.Routes) != 2 {
+				t.Fatalf("Expected 2 routes, got %d", len(config.Routes))
+			}
+			route := config.Routes[1]
+			if route.Method != "POST" || route.ResponseStatus != 201 {
+				t.Errorf("Expected POST 201, got %s %d", route.Method, route.ResponseStatus)
+			}
+			if got := route.ResponseHeader["Content-Type"]; len(got) != 1 || got[0] != "application/json" {
+				t.Errorf("Expected Content-Type application/json, got %v", got)
+			}
+			if got := route.ResponseHeader["Vary"]; !slices.Equal(got, HeaderValues{"Accept", "Origin"}) {
+				t.Errorf("Expected Vary values [Accept Origin], got %v", got)
+			}
+			if len(route.Conditions) != 1 || route.Conditions[0].HeaderMatch["X-Test"] != "1" {
+				t.Errorf("Expected one condition matching X-Test, got %+v", route.Conditions)
+			}
+			if strings.Contains(tt.content, `\ud83d`) && config.Routes[0].ResponseBody != "\U0001F600" {
+				t.Errorf("Expected the surrogate pair to decode to an emoji, got %q", config.Routes[0].ResponseBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package configs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// resolver returns the value of a single reference without its ${ }
+type resolver func(expr string) (string, error)
+
+// interpolateConfig expands ${VAR}, ${VAR:-default} and ${file:/path}
+// references in every string of the config, including list entries and map
+// values. Relative ${file:...} paths are resolved against baseDir.
+func interpolateConfig(config *ServerConfig, baseDir string) error {
+	return interpolateValue(reflect.ValueOf(config).Elem(), "", localResolver(baseDir))
+}
+
+// interpolateRemoteConfig expands the references of a config fetched over
+// HTTP. Its values are served to clients and it is written by whoever
+// controls the URL, so it may not read local files or the environment; only
+// $${ escapes are expanded.
+func interpolateRemoteConfig(config *ServerConfig) error {
+	return interpolateValue(reflect.ValueOf(config).Elem(), "", func(expr string) (string, error) {
+		return "", fmt.Errorf("${%s}: remote configs cannot reference files or environment variables", expr)
+	})
+}
+
+// interpolateValue walks v and expands every settable string in place
+func interpolateValue(v reflect.Value, path string, resolve resolver) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return interpolateValue(v.Elem(), path, resolve)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" {
+				name = field.Name
+			}
+			if err := interpolateValue(v.Field(i), joinPath(path, name), resolve); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := interpolateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), resolve); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// Map values are not addressable, so each one is expanded in a copy
+		// that replaces it
+		iter := v.MapRange()
+		for iter.Next() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(iter.Value())
+			if value.Kind() == reflect.Slice {
+				// Do not write through to the slice shared with the original
+				value.Set(reflect.AppendSlice(reflect.MakeSlice(value.Type(), 0, value.Len()), value))
+			}
+			if err := interpolateValue(value, joinPath(path, fmt.Sprint(iter.Key())), resolve); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), value)
+		}
+	case reflect.String:
+		if !v.CanSet() {
+			return nil
+		}
+		expanded, err := expandString(v.String(), resolve)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		v.SetString(expanded)
+	}
+
+	return nil
+}
+
+// expandString replaces the ${...} references in s. "$${" produces a literal
+// "${", and a "$" that is not followed by "{" is left untouched.
+func expandString(s string, resolve resolver) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+
+		// An escaped reference is copied without its leading "$"
+		if start > 0 && s[start-1] == '$' {
+			b.WriteString(s[:start])
+			b.WriteString("{")
+			s = s[start+2:]
+			continue
+		}
+
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated reference %q", s[start:])
+		}
+		end += start
+
+		value, err := resolve(s[start+2 : end])
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(s[:start])
+		b.WriteString(value)
+		s = s[end+1:]
+	}
+}
+
+// localResolver resolves references to environment variables and to files,
+// relative to baseDir
+func localResolver(baseDir string) resolver {
+	return func(expr string) (string, error) {
+		return resolveReference(expr, baseDir)
+	}
+}
+
+// resolveReference returns the value of a single reference without its ${ }
+func resolveReference(expr, baseDir string) (string, error) {
+	if path, ok := strings.CutPrefix(expr, "file:"); ok {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read ${%s}: %w", expr, err)
+		}
+		// Secrets mounted as files usually end with a newline that is not part of the value
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	name, fallback, hasDefault := strings.Cut(expr, ":-")
+	if !validEnvName(name) {
+		return "", fmt.Errorf("invalid variable name in ${%s}", expr)
+	}
+
+	value, ok := os.LookupEnv(name)
+	if hasDefault && value == "" {
+		return fallback, nil
+	}
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set (use ${%s:-default} to provide a default)", name, name)
+	}
+	return value, nil
+}
+
+// validEnvName reports whether name is a valid environment variable name
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
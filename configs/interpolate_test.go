@@ -0,0 +1,171 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandString(t *testing.T) {
+	secretDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(secretDir, "token"), []byte("s3cret\n"), 0600); err != nil {
+		t.Fatalf("Failed to write secret file: %v", err)
+	}
+
+	t.Setenv("ECHO2_TEST_TOKEN", "abc123")
+	t.Setenv("ECHO2_TEST_EMPTY", "")
+
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:     "no references",
+			input:    "plain $text",
+			expected: "plain $text",
+		},
+		{
+			name:     "set variable",
+			input:    "Bearer ${ECHO2_TEST_TOKEN}",
+			expected: "Bearer abc123",
+		},
+		{
+			name:     "default for unset variable",
+			input:    ":${ECHO2_TEST_UNSET:-8080}",
+			expected: ":8080",
+		},
+		{
+			name:     "default for empty variable",
+			input:    "${ECHO2_TEST_EMPTY:-fallback}",
+			expected: "fallback",
+		},
+		{
+			name:     "default ignored when set",
+			input:    "${ECHO2_TEST_TOKEN:-fallback}",
+			expected: "abc123",
+		},
+		{
+			name:     "empty default",
+			input:    "[${ECHO2_TEST_UNSET:-}]",
+			expected: "[]",
+		},
+		{
+			name:     "escaped reference",
+			input:    "literal $${ECHO2_TEST_TOKEN} and ${ECHO2_TEST_TOKEN}",
+			expected: "literal ${ECHO2_TEST_TOKEN} and abc123",
+		},
+		{
+			name:     "relative file reference",
+			input:    "${file:token}",
+			expected: "s3cret",
+		},
+		{
+			name:     "absolute file reference",
+			input:    "${file:" + filepath.Join(secretDir, "token") + "}",
+			expected: "s3cret",
+		},
+		{
+			name:        "unset variable without default",
+			input:       "${ECHO2_TEST_UNSET}",
+			expectedErr: "environment variable ECHO2_TEST_UNSET is not set",
+		},
+		{
+			name:        "missing file",
+			input:       "${file:missing}",
+			expectedErr: "failed to read ${file:missing}",
+		},
+		{
+			name:        "invalid name",
+			input:       "${1BAD}",
+			expectedErr: "invalid variable name",
+		},
+		{
+			name:        "unterminated reference",
+			input:       "${ECHO2_TEST_TOKEN",
+			expectedErr: "unterminated reference",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandString(tt.input, localResolver(secretDir))
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandString() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Interpolation(t *testing.T) {
+	t.Setenv("ECHO2_TEST_PORT", "9999")
+	t.Setenv("ECHO2_TEST_TOKEN", "abc123")
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	configContent := `address: ":${ECHO2_TEST_PORT}"
+log_level: "${ECHO2_TEST_LOG_LEVEL:-warn}"
+routes:
+  - path: "/secure"
+    response_header:
+      X-Token: "${ECHO2_TEST_TOKEN}"
+      Link: ["</a?t=${ECHO2_TEST_TOKEN}>", "</b>"]
+    conditions:
+      - header_match:
+          Authorization: "Bearer ${ECHO2_TEST_TOKEN}"
+        response_body: "ok"
+`
+	if err := os.WriteFile(configFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if config.Address != ":9999" {
+		t.Errorf("Expected address :9999, got %s", config.Address)
+	}
+	if config.LogLevel != "warn" {
+		t.Errorf("Expected log level warn, got %s", config.LogLevel)
+	}
+	if got := config.Routes[0].ResponseHeader["X-Token"]; len(got) != 1 || got[0] != "abc123" {
+		t.Errorf("Expected X-Token header abc123, got %v", got)
+	}
+	if got := config.Routes[0].ResponseHeader["Link"]; len(got) != 2 || got[0] != "</a?t=abc123>" || got[1] != "</b>" {
+		t.Errorf("Expected every Link value to be expanded, got %v", got)
+	}
+	if got := config.Routes[0].Conditions[0].HeaderMatch["Authorization"]; got != "Bearer abc123" {
+		t.Errorf("Expected Authorization match %q, got %q", "Bearer abc123", got)
+	}
+
+	t.Run("error names the field", func(t *testing.T) {
+		badFile := filepath.Join(dir, "bad.yaml")
+		if err := os.WriteFile(badFile, []byte(`routes:
+  - path: "/x"
+    conditions:
+      - header_match:
+          X-Key: "${ECHO2_TEST_UNSET}"
+`), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+
+		_, err := LoadConfig(badFile)
+		expected := "routes[0].conditions[0].header_match.X-Key: environment variable ECHO2_TEST_UNSET is not set"
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q, got %v", expected, err)
+		}
+	})
+}
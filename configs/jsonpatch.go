@@ -0,0 +1,375 @@
+package configs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// JSON Patch operations accepted in response_echo.patch
+const (
+	JSONPatchAdd     = "add"
+	JSONPatchRemove  = "remove"
+	JSONPatchReplace = "replace"
+	JSONPatchMove    = "move"
+	JSONPatchCopy    = "copy"
+	JSONPatchTest    = "test"
+)
+
+// validate checks the operation name and its JSON Pointers
+func (o *JSONPatchOperation) validate() error {
+	switch o.Op {
+	case JSONPatchAdd, JSONPatchRemove, JSONPatchReplace, JSONPatchTest:
+	case JSONPatchMove, JSONPatchCopy:
+		if _, err := ParseJSONPointer(o.From); err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+	default:
+		return fmt.Errorf("invalid op '%s', expected add, remove, replace, move, copy or test", o.Op)
+	}
+	if _, err := ParseJSONPointer(o.Path); err != nil {
+		return fmt.Errorf("path: %w", err)
+	}
+	if _, err := json.Marshal(o.Value); err != nil {
+		return fmt.Errorf("value cannot be represented as JSON: %w", err)
+	}
+	return nil
+}
+
+// ParseJSONPointer splits an RFC 6901 JSON Pointer such as /items/0/name into
+// its unescaped reference tokens. The empty pointer refers to the whole
+// document and has no tokens.
+func ParseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON Pointer '%s', expected it to start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		// ~ only escapes / as ~1 and itself as ~0
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, fmt.Errorf("invalid JSON Pointer '%s', ~ must be followed by 0 or 1", pointer)
+			}
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// ApplyJSONPatch applies RFC 6902 operations to a JSON document in order and
+// returns the patched document. Numbers keep their original representation.
+// The first operation that fails, including a failed test, aborts the patch.
+func ApplyJSONPatch(document []byte, operations []JSONPatchOperation) ([]byte, error) {
+	doc, err := decodeJSON(document)
+	if err != nil {
+		return nil, fmt.Errorf("request body is not JSON: %w", err)
+	}
+
+	for i, operation := range operations {
+		doc, err = applyOperation(doc, operation)
+		if err != nil {
+			return nil, fmt.Errorf("patch[%d] (%s %s): %w", i, operation.Op, operation.Path, err)
+		}
+	}
+
+	return json.Marshal(doc)
+}
+
+// applyOperation applies a single operation to doc and returns the new document
+func applyOperation(doc any, operation JSONPatchOperation) (any, error) {
+	path, err := ParseJSONPointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case JSONPatchAdd, JSONPatchReplace, JSONPatchTest:
+		value, err := jsonValue(operation.Value)
+		if err != nil {
+			return nil, err
+		}
+		switch operation.Op {
+		case JSONPatchAdd:
+			return addValue(doc, path, value)
+		case JSONPatchReplace:
+			return replaceValue(doc, path, value)
+		}
+		current, err := valueAt(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equalJSON(current, value) {
+			return nil, errors.New("test failed, the value differs")
+		}
+		return doc, nil
+	case JSONPatchRemove:
+		return removeValue(doc, path)
+	case JSONPatchMove, JSONPatchCopy:
+		from, err := ParseJSONPointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := valueAt(doc, from)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		if operation.Op == JSONPatchCopy {
+			return addValue(doc, path, cloneJSON(value))
+		}
+		if len(path) > len(from) && slices.Equal(path[:len(from)], from) {
+			return nil, errors.New("cannot move a value into itself")
+		}
+		if doc, err = removeValue(doc, from); err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	}
+
+	return nil, fmt.Errorf("invalid op '%s'", operation.Op)
+}
+
+// valueAt returns the value path refers to
+func valueAt(doc any, path []string) (any, error) {
+	for _, token := range path {
+		var err error
+		if doc, err = child(doc, token); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// addValue inserts value at path. An object member is created or replaced, an
+// array element is inserted before the index, or appended for "-".
+func addValue(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, path, func(parent any, token string) (any, error) {
+		switch parent := parent.(type) {
+		case map[string]any:
+			parent[token] = value
+			return parent, nil
+		case []any:
+			if token == "-" {
+				return append(parent, value), nil
+			}
+			i, err := arrayIndex(token, len(parent)+1)
+			if err != nil {
+				return nil, err
+			}
+			return slices.Insert(parent, i, value), nil
+		}
+		return nil, fmt.Errorf("cannot add '%s' to a %s", token, jsonKind(parent))
+	})
+}
+
+// replaceValue replaces the existing value at path
+func replaceValue(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, path, func(parent any, token string) (any, error) {
+		if _, err := child(parent, token); err != nil {
+			return nil, err
+		}
+		switch parent := parent.(type) {
+		case map[string]any:
+			parent[token] = value
+			return parent, nil
+		case []any:
+			i, _ := arrayIndex(token, len(parent))
+			parent[i] = value
+			return parent, nil
+		}
+		return nil, fmt.Errorf("cannot replace '%s' in a %s", token, jsonKind(parent))
+	})
+}
+
+// removeValue removes the existing value at path
+func removeValue(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, errors.New("cannot remove the whole document")
+	}
+	return updateParent(doc, path, func(parent any, token string) (any, error) {
+		if _, err := child(parent, token); err != nil {
+			return nil, err
+		}
+		switch parent := parent.(type) {
+		case map[string]any:
+			delete(parent, token)
+			return parent, nil
+		case []any:
+			i, _ := arrayIndex(token, len(parent))
+			return slices.Delete(parent, i, i+1), nil
+		}
+		return nil, fmt.Errorf("cannot remove '%s' from a %s", token, jsonKind(parent))
+	})
+}
+
+// updateParent walks to the container holding the last token of path, lets
+// update change it and stores the changed container back into its own
+// parent, since arrays may be reallocated
+func updateParent(doc any, path []string, update func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return update(doc, path[0])
+	}
+
+	next, err := child(doc, path[0])
+	if err != nil {
+		return nil, err
+	}
+	next, err = updateParent(next, path[1:], update)
+	if err != nil {
+		return nil, err
+	}
+
+	switch doc := doc.(type) {
+	case map[string]any:
+		doc[path[0]] = next
+	case []any:
+		i, _ := arrayIndex(path[0], len(doc))
+		doc[i] = next
+	}
+	return doc, nil
+}
+
+// child returns the member or element of a container named by token
+func child(doc any, token string) (any, error) {
+	switch doc := doc.(type) {
+	case map[string]any:
+		value, ok := doc[token]
+		if !ok {
+			return nil, fmt.Errorf("member '%s' not found", token)
+		}
+		return value, nil
+	case []any:
+		i, err := arrayIndex(token, len(doc))
+		if err != nil {
+			return nil, err
+		}
+		return doc[i], nil
+	}
+	return nil, fmt.Errorf("cannot find '%s' in a %s", token, jsonKind(doc))
+}
+
+// arrayIndex parses an array index token, which must be below limit and
+// have no leading zeros
+func arrayIndex(token string, limit int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index '%s'", token)
+	}
+	if i >= limit {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+// jsonKind names the JSON type of a decoded value for error messages
+func jsonKind(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+// decodeJSON decodes a JSON document keeping numbers as json.Number
+func decodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after the document")
+	}
+	return doc, nil
+}
+
+// jsonValue converts a patch value from the config into the representation
+// decodeJSON produces
+func jsonValue(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(data)
+}
+
+// cloneJSON deep copies a decoded JSON value
+func cloneJSON(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		clone := make(map[string]any, len(value))
+		for k, v := range value {
+			clone[k] = cloneJSON(v)
+		}
+		return clone
+	case []any:
+		clone := make([]any, len(value))
+		for i, v := range value {
+			clone[i] = cloneJSON(v)
+		}
+		return clone
+	}
+	return value
+}
+
+// equalJSON compares two decoded JSON values, treating numbers by value so
+// that 1 and 1.0 are equal
+func equalJSON(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, errA := a.Float64()
+		y, errB := b.Float64()
+		return errA == nil && errB == nil && x == y
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			other, ok := b[k]
+			if !ok || !equalJSON(v, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equalJSON(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
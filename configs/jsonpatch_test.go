@@ -0,0 +1,148 @@
+package configs
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseJSONPointer(t *testing.T) {
+	tests := []struct {
+		pointer  string
+		expected []string
+		err      string
+	}{
+		{"", nil, ""},
+		{"/", []string{""}, ""},
+		{"/items/0/name", []string{"items", "0", "name"}, ""},
+		{"/a~1b/m~0n/~01", []string{"a/b", "m~n", "~1"}, ""},
+		{"items", nil, "expected it to start with /"},
+		{"/a~2", nil, "~ must be followed by 0 or 1"},
+		{"/a~", nil, "~ must be followed by 0 or 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			tokens, err := ParseJSONPointer(tt.pointer)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !slices.Equal(tokens, tt.expected) {
+				t.Errorf("Expected tokens %q, got %q", tt.expected, tokens)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		document   string
+		operations []JSONPatchOperation
+		expected   string
+		err        string
+	}{
+		{
+			name:       "add member",
+			document:   `{"foo":"bar"}`,
+			operations: []JSONPatchOperation{{Op: "add", Path: "/baz", Value: "qux"}},
+			expected:   `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			name:       "add array element",
+			document:   `{"foo":["bar","baz"]}`,
+			operations: []JSONPatchOperation{{Op: "add", Path: "/foo/1", Value: "qux"}, {Op: "add", Path: "/foo/-", Value: 1}},
+			expected:   `{"foo":["bar","qux","baz",1]}`,
+		},
+		{
+			name:       "add nested object from config",
+			document:   `{}`,
+			operations: []JSONPatchOperation{{Op: "add", Path: "/meta", Value: map[string]any{"echoed": true, "tags": []any{"a"}}}},
+			expected:   `{"meta":{"echoed":true,"tags":["a"]}}`,
+		},
+		{
+			name:       "remove and replace",
+			document:   `{"a":1,"b":[1,2,3],"c":{"d":4}}`,
+			operations: []JSONPatchOperation{{Op: "remove", Path: "/a"}, {Op: "remove", Path: "/b/1"}, {Op: "replace", Path: "/c/d", Value: "four"}},
+			expected:   `{"b":[1,3],"c":{"d":"four"}}`,
+		},
+		{
+			name:       "move and copy",
+			document:   `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			operations: []JSONPatchOperation{{Op: "move", From: "/foo/waldo", Path: "/qux/thud"}, {Op: "copy", From: "/qux", Path: "/copy"}},
+			expected:   `{"copy":{"corge":"grault","thud":"fred"},"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name:       "successful test keeps numbers",
+			document:   `{"price":10.50,"id":12345678901234567890}`,
+			operations: []JSONPatchOperation{{Op: "test", Path: "/price", Value: 10.5}},
+			expected:   `{"id":12345678901234567890,"price":10.50}`,
+		},
+		{
+			name:       "replace whole document",
+			document:   `[1]`,
+			operations: []JSONPatchOperation{{Op: "replace", Path: "", Value: map[string]any{"replaced": true}}},
+			expected:   `{"replaced":true}`,
+		},
+		{
+			name:       "failed test",
+			document:   `{"baz":"qux"}`,
+			operations: []JSONPatchOperation{{Op: "test", Path: "/baz", Value: "bar"}},
+			err:        "patch[0] (test /baz): test failed",
+		},
+		{
+			name:       "missing member",
+			document:   `{"a":{}}`,
+			operations: []JSONPatchOperation{{Op: "add", Path: "/a/b", Value: 1}, {Op: "replace", Path: "/a/c", Value: 1}},
+			err:        "patch[1] (replace /a/c): member 'c' not found",
+		},
+		{
+			name:       "index out of range",
+			document:   `[1,2]`,
+			operations: []JSONPatchOperation{{Op: "add", Path: "/3", Value: 1}},
+			err:        "array index 3 out of range",
+		},
+		{
+			name:       "leading zero index",
+			document:   `[1,2]`,
+			operations: []JSONPatchOperation{{Op: "remove", Path: "/01"}},
+			err:        "invalid array index '01'",
+		},
+		{
+			name:       "move into itself",
+			document:   `{"a":{"b":1}}`,
+			operations: []JSONPatchOperation{{Op: "move", From: "/a", Path: "/a/c"}},
+			err:        "cannot move a value into itself",
+		},
+		{
+			name:       "not JSON",
+			document:   `hello`,
+			operations: []JSONPatchOperation{{Op: "remove", Path: "/a"}},
+			err:        "request body is not JSON",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patched, err := ApplyJSONPatch([]byte(tt.document), tt.operations)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(patched) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, patched)
+			}
+		})
+	}
+}
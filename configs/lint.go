@@ -0,0 +1,193 @@
+package configs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// LintWarning describes a configuration entry that is valid but most likely
+// does not behave the way its author intended.
+type LintWarning struct {
+	Route     int    // Index of the offending route
+	Condition int    // Index of the offending condition, or -1 for the route itself
+	Source    string // File and line of the offending route, when known
+	Message   string // Human-readable description of the problem
+}
+
+// String formats the warning with its route (and condition) position
+func (w LintWarning) String() string {
+	position := fmt.Sprintf("route %d", w.Route)
+	if w.Source != "" {
+		position += " (" + w.Source + ")"
+	}
+	if w.Condition >= 0 {
+		position += fmt.Sprintf(", condition %d", w.Condition)
+	}
+	return position + ": " + w.Message
+}
+
+// Lint inspects a loaded configuration for unreachable conditions, JSON
+// response bodies that do not parse, client certificate conditions the TLS
+// settings can never satisfy, cookies browsers reject and options that have
+// no effect. Routes that would shadow each other are rejected when the
+// config is loaded. Warnings are returned in route order.
+func Lint(config *ServerConfig) []LintWarning {
+	var warnings []LintWarning
+
+	for i, route := range config.Routes {
+		if msg := lintJSONBody(route.ResponseBody, route.ResponseHeader); msg != "" {
+			warnings = append(warnings, LintWarning{Route: i, Condition: -1, Source: route.Source(), Message: msg})
+		}
+
+		if !route.ResponseEcho.Enabled && (len(route.ResponseEcho.Patch) > 0 || route.ResponseEcho.Uppercase || route.ResponseEcho.Envelope) {
+			warnings = append(warnings, LintWarning{Route: i, Condition: -1, Source: route.Source(), Message: "response_echo options have no effect without response_echo.enabled"})
+		}
+
+		if generate := route.ResponseGenerate; !generate.IsEnabled() && (generate.Size != "" || generate.Text != "" || generate.Count != 0 || len(generate.Schema) > 0) {
+			warnings = append(warnings, LintWarning{Route: i, Condition: -1, Source: route.Source(), Message: "response_generate options have no effect without response_generate.type"})
+		}
+
+		// A generated body replaces every configured one
+		if route.ResponseGenerate.IsEnabled() {
+			if route.ResponseBody != "" {
+				warnings = append(warnings, LintWarning{Route: i, Condition: -1, Source: route.Source(), Message: "response_body has no effect with response_generate"})
+			}
+			for j, condition := range route.Conditions {
+				if condition.ResponseBody != "" {
+					warnings = append(warnings, LintWarning{Route: i, Condition: j, Source: route.Source(), Message: "response_body has no effect with response_generate, the generated body is sent"})
+				}
+			}
+		}
+
+		for _, msg := range lintCookies(route.ResponseCookies) {
+			warnings = append(warnings, LintWarning{Route: i, Condition: -1, Source: route.Source(), Message: msg})
+		}
+
+		if route.DumpFormat != "" && !route.ResponseDump {
+			warnings = append(warnings, LintWarning{Route: i, Condition: -1, Source: route.Source(), Message: "dump_format has no effect without response_dump"})
+		}
+
+		for j, condition := range route.Conditions {
+			// Conditions are evaluated in order, so a condition whose requirements
+			// include all of an earlier condition's requirements can never win
+			for k := 0; k < j; k++ {
+				earlier := route.Conditions[k]
+				if headerMatchSubset(earlier.HeaderMatch, condition.HeaderMatch) &&
+					cookieMatchSubset(earlier.CookieMatch, condition.CookieMatch) && earlier.ClientCert.subsetOf(condition.ClientCert) &&
+					earlier.ClientIP.subsetOf(condition.ClientIP) {
+					warnings = append(warnings, LintWarning{
+						Route:     i,
+						Condition: j,
+						Source:    route.Source(),
+						Message:   fmt.Sprintf("condition is unreachable, condition %d always matches first", k),
+					})
+					break
+				}
+			}
+
+			if len(condition.HeaderMatch) == 0 && len(condition.CookieMatch) == 0 && condition.ClientCert == nil && condition.ClientIP == nil {
+				warnings = append(warnings, LintWarning{
+					Route:     i,
+					Condition: j,
+					Source:    route.Source(),
+					Message:   "condition has no header_match and matches every request, the route's default response is never used",
+				})
+			}
+
+			if condition.ClientCert != nil && !requestsClientCert(config, route) {
+				warnings = append(warnings, LintWarning{
+					Route:     i,
+					Condition: j,
+					Source:    route.Source(),
+					Message:   "condition has client_cert but tls.client_auth is none on every listener serving the route, so it never matches",
+				})
+			}
+
+			if msg := lintJSONBody(condition.ResponseBody, condition.ResponseHeader); msg != "" {
+				warnings = append(warnings, LintWarning{Route: i, Condition: j, Source: route.Source(), Message: msg})
+			}
+			for _, msg := range lintCookies(condition.ResponseCookies) {
+				warnings = append(warnings, LintWarning{Route: i, Condition: j, Source: route.Source(), Message: msg})
+			}
+		}
+	}
+
+	return warnings
+}
+
+// requestsClientCert reports whether a listener serving route asks clients
+// for a certificate
+func requestsClientCert(config *ServerConfig, route Route) bool {
+	for _, listener := range config.GetListeners() {
+		if route.ServedOn(listener.Name) && listener.TLS.GetClientAuth() != ClientAuthNone {
+			return true
+		}
+	}
+	return false
+}
+
+// lintJSONBody reports a problem when the headers declare a JSON content type
+// but the body is not valid JSON. An empty string means no problem.
+func lintJSONBody(body string, headers map[string]HeaderValues) string {
+	if body == "" {
+		return ""
+	}
+
+	for key, values := range headers {
+		if !strings.EqualFold(key, "Content-Type") || len(values) == 0 {
+			continue
+		}
+		value := values[len(values)-1]
+		mediaType := strings.ToLower(strings.TrimSpace(strings.Split(value, ";")[0]))
+		if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+			return ""
+		}
+		if !json.Valid([]byte(body)) {
+			return fmt.Sprintf("response_body is not valid JSON but Content-Type is %q", value)
+		}
+	}
+
+	return ""
+}
+
+// lintCookies reports cookies that browsers reject: same_site none is only
+// accepted on secure cookies
+func lintCookies(cookies []Cookie) []string {
+	var messages []string
+	for k, cookie := range cookies {
+		if strings.EqualFold(cookie.SameSite, SameSiteNone) && !cookie.Secure {
+			messages = append(messages, fmt.Sprintf("response_cookies[%d] (%s): same_site none without secure, browsers drop the cookie", k, cookie.Name))
+		}
+	}
+	return messages
+}
+
+// headerMatchSubset reports whether every requirement in subset also appears in
+// superset, comparing header names case-insensitively like MatchesHeaders does.
+func headerMatchSubset(subset, superset map[string]string) bool {
+	for subKey, subValue := range subset {
+		found := false
+		for superKey, superValue := range superset {
+			if strings.EqualFold(subKey, superKey) && subValue == superValue {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// cookieMatchSubset reports whether every cookie requirement in subset is
+// also in superset. Unlike headers, cookie names are case-sensitive.
+func cookieMatchSubset(subset, superset map[string]string) bool {
+	for name, value := range subset {
+		if superValue, ok := superset[name]; !ok || superValue != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,236 @@
+package configs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   ServerConfig
+		expected []string
+	}{
+		{
+			name: "clean config",
+			config: ServerConfig{
+				Routes: []Route{
+					{Path: "/health", ResponseBody: "OK"},
+					{
+						Path:           "/api/users",
+						ResponseBody:   `{"users": []}`,
+						ResponseHeader: map[string]HeaderValues{"Content-Type": {"application/json"}},
+					},
+				},
+			},
+			expected: nil,
+		},
+		{
+			name: "response_echo options without enabled",
+			config: ServerConfig{
+				Routes: []Route{
+					{Path: "/echo", ResponseEcho: EchoConfig{Enabled: true, Uppercase: true}},
+					{Path: "/plain", ResponseEcho: EchoConfig{Envelope: true}},
+				},
+			},
+			expected: []string{"route 1: response_echo options have no effect without response_echo.enabled"},
+		},
+		{
+			name: "response_generate options without type",
+			config: ServerConfig{
+				Routes: []Route{
+					{Path: "/generate", ResponseGenerate: GenerateConfig{Type: GenerateText, Size: "1KB"}},
+					{Path: "/plain", ResponseGenerate: GenerateConfig{Count: 10}},
+				},
+			},
+			expected: []string{"route 1: response_generate options have no effect without response_generate.type"},
+		},
+		{
+			name: "response_body with response_generate",
+			config: ServerConfig{
+				Routes: []Route{
+					{
+						Path:             "/generate",
+						ResponseBody:     "ignored",
+						ResponseGenerate: GenerateConfig{Type: GenerateLorem, Size: "1KB"},
+						Conditions: []RouteCondition{
+							{HeaderMatch: map[string]string{"X-Test": "1"}, ResponseStatus: 503},
+							{HeaderMatch: map[string]string{"X-Test": "2"}, ResponseBody: "also ignored"},
+						},
+					},
+				},
+			},
+			expected: []string{
+				"route 0: response_body has no effect with response_generate",
+				"route 0, condition 1: response_body has no effect with response_generate",
+			},
+		},
+		{
+			name: "dump_format without response_dump",
+			config: ServerConfig{
+				Routes: []Route{
+					{Path: "/dump", ResponseDump: true, DumpFormat: "yaml"},
+					{Path: "/plain", DumpFormat: "yaml"},
+				},
+			},
+			expected: []string{"route 1: dump_format has no effect without response_dump"},
+		},
+		{
+			name: "unreachable condition",
+			config: ServerConfig{
+				Routes: []Route{
+					{
+						Path: "/secure",
+						Conditions: []RouteCondition{
+							{HeaderMatch: map[string]string{"Authorization": "Bearer a"}},
+							{HeaderMatch: map[string]string{"authorization": "Bearer a", "X-Client-ID": "mobile"}},
+						},
+					},
+				},
+			},
+			expected: []string{"route 0, condition 1: condition is unreachable, condition 0 always matches first"},
+		},
+		{
+			name: "condition without header_match",
+			config: ServerConfig{
+				Routes: []Route{
+					{
+						Path:       "/any",
+						Conditions: []RouteCondition{{ResponseBody: "always"}},
+					},
+				},
+			},
+			expected: []string{"route 0, condition 0: condition has no header_match"},
+		},
+		{
+			name: "client certificate conditions",
+			config: ServerConfig{
+				TLS: TLSConfig{SelfSigned: true, ClientAuth: ClientAuthRequire},
+				Routes: []Route{
+					{
+						Path: "/partner",
+						Conditions: []RouteCondition{
+							{ClientCert: &ClientCertMatch{CommonName: "partner-a"}},
+							{ClientCert: &ClientCertMatch{CommonName: "partner-b"}},
+							{
+								HeaderMatch: map[string]string{"X-Test": "1"},
+								ClientCert:  &ClientCertMatch{CommonName: "partner-a", SAN: "a.example.com"},
+							},
+						},
+					},
+				},
+			},
+			expected: []string{"route 0, condition 2: condition is unreachable, condition 0 always matches first"},
+		},
+		{
+			name: "client ip conditions",
+			config: ServerConfig{
+				Routes: []Route{
+					{
+						Path: "/internal",
+						Conditions: []RouteCondition{
+							{ClientIP: validClientIP([]string{"10.0.0.0/8"}, nil)},
+							{ClientIP: validClientIP([]string{"10.1.0.0/16"}, nil)},
+							{ClientIP: validClientIP(nil, []string{"10.0.0.0/8"})},
+						},
+					},
+				},
+			},
+			expected: []string{"route 0, condition 1: condition is unreachable, condition 0 always matches first"},
+		},
+		{
+			name: "cookie conditions",
+			config: ServerConfig{
+				Routes: []Route{
+					{
+						Path: "/profile",
+						Conditions: []RouteCondition{
+							{CookieMatch: map[string]string{"session": "abc"}},
+							{CookieMatch: map[string]string{"Session": "abc"}},
+							{CookieMatch: map[string]string{"session": "abc"}, HeaderMatch: map[string]string{"X-Test": "1"}},
+						},
+					},
+				},
+			},
+			expected: []string{"route 0, condition 2: condition is unreachable, condition 0 always matches first"},
+		},
+		{
+			name: "client certificate condition without client auth",
+			config: ServerConfig{
+				Routes: []Route{
+					{
+						Path:       "/partner",
+						Conditions: []RouteCondition{{ClientCert: &ClientCertMatch{CommonName: "partner-a"}}},
+					},
+				},
+			},
+			expected: []string{"route 0, condition 0: condition has client_cert but tls.client_auth is none"},
+		},
+		{
+			name: "invalid JSON bodies",
+			config: ServerConfig{
+				Routes: []Route{
+					{
+						Path:           "/broken",
+						ResponseBody:   `{"users": [}`,
+						ResponseHeader: map[string]HeaderValues{"content-type": {"application/json; charset=utf-8"}},
+						Conditions: []RouteCondition{
+							{
+								HeaderMatch:    map[string]string{"X-Test": "1"},
+								ResponseBody:   "not json",
+								ResponseHeader: map[string]HeaderValues{"Content-Type": {"application/problem+json"}},
+							},
+							{
+								HeaderMatch:    map[string]string{"X-Test": "2"},
+								ResponseBody:   "plain text is fine",
+								ResponseHeader: map[string]HeaderValues{"Content-Type": {"text/plain"}},
+							},
+						},
+					},
+				},
+			},
+			expected: []string{
+				"route 0: response_body is not valid JSON",
+				"route 0, condition 0: response_body is not valid JSON",
+			},
+		},
+		{
+			name: "same_site none without secure",
+			config: ServerConfig{
+				Routes: []Route{
+					{
+						Path: "/login",
+						ResponseCookies: []Cookie{
+							{Name: "session", SameSite: "None"},
+							{Name: "tracking", SameSite: SameSiteNone, Secure: true},
+						},
+						Conditions: []RouteCondition{
+							{
+								HeaderMatch:     map[string]string{"X-Test": "1"},
+								ResponseCookies: []Cookie{{Name: "session", SameSite: SameSiteNone}},
+							},
+						},
+					},
+				},
+			},
+			expected: []string{
+				"route 0: response_cookies[0] (session): same_site none without secure",
+				"route 0, condition 0: response_cookies[0] (session): same_site none without secure",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := Lint(&tt.config)
+			if len(warnings) != len(tt.expected) {
+				t.Fatalf("Expected %d warnings, got %d: %v", len(tt.expected), len(warnings), warnings)
+			}
+			for i, expected := range tt.expected {
+				if !strings.HasPrefix(warnings[i].String(), expected) {
+					t.Errorf("Expected warning %d to start with %q, got %q", i, expected, warnings[i].String())
+				}
+			}
+		})
+	}
+}
@@ -2,23 +2,49 @@ package configs
 
 import (
 	"fmt"
+	"maps"
+	"net"
+	"net/netip"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
-// LoadConfig loads server configuration from a YAML file
+// LoadConfig loads server configuration from a YAML, JSON or TOML file, a
+// directory of them, standard input ("-") or an http(s) URL, following any
+// include entries
 func LoadConfig(filePath string) (*ServerConfig, error) {
-	// Read the YAML file
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+	return LoadConfigs(filePath)
+}
+
+// LoadConfigs loads and merges the configuration from several sources, each
+// accepted by LoadConfig. Routes are merged in a defined order:
+//   - paths in the order they are given
+//   - the config files (*.yaml, *.yml, *.json, *.toml) of a directory in
+//     lexical order
+//   - a file's own routes, followed by the routes of its includes in the
+//     order they are listed (glob matches in lexical order)
+//
+// Each file is loaded at most once. Settings such as address may be set by
+// several files only if they agree.
+func LoadConfigs(paths ...string) (*ServerConfig, error) {
+	loader := &configLoader{
+		loaded:   make(map[string]bool),
+		active:   make(map[string]bool),
+		settings: make(map[string]string),
 	}
 
-	// Parse the YAML content
 	var config ServerConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	for _, path := range paths {
+		if err := loader.loadPath(path, &config); err != nil {
+			return nil, err
+		}
 	}
 
 	// Validate the configuration
@@ -29,16 +55,263 @@ func LoadConfig(filePath string) (*ServerConfig, error) {
 	return &config, nil
 }
 
+// configLoader tracks the state needed to merge several config files
+type configLoader struct {
+	loaded   map[string]bool   // Files already merged, by absolute path
+	active   map[string]bool   // Files currently being loaded, to detect include cycles
+	settings map[string]string // File that set each top-level setting, by YAML key
+}
+
+// loadPath merges a config source into config. The path may be a file, a
+// directory of config files, StdinPath or an http(s) URL.
+func (l *configLoader) loadPath(path string, config *ServerConfig) error {
+	if path == StdinPath || IsRemote(path) {
+		return l.loadFile(path, config)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if !info.IsDir() {
+		return l.loadFile(path, config)
+	}
+
+	files, err := configFilesInDir(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config directory: %w", err)
+	}
+	for _, file := range files {
+		if err := l.loadFile(file, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadFile parses a single config source, merges it into config and then
+// loads its includes
+func (l *configLoader) loadFile(path string, config *ServerConfig) error {
+	key, err := sourceKey(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve config path: %w", err)
+	}
+	if l.active[key] {
+		return fmt.Errorf("include cycle: %s includes itself", sourceName(path))
+	}
+	if l.loaded[key] {
+		return nil
+	}
+	l.loaded[key] = true
+	l.active[key] = true
+	defer delete(l.active, key)
+
+	// Read the config file, standard input or URL
+	data, format, err := readSource(path)
+	if err != nil {
+		return err
+	}
+
+	name := sourceName(path)
+	file, err := parseConfig(data, name, format)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal config %s: %w", name, err)
+	}
+
+	// Expand environment variables and secrets before includes are resolved
+	if IsRemote(path) {
+		err = interpolateRemoteConfig(file)
+	} else {
+		err = interpolateConfig(file, sourceDir(path))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	if err := l.merge(config, file, name); err != nil {
+		return err
+	}
+
+	for _, pattern := range file.Include {
+		paths, err := resolveInclude(path, pattern)
+		if err != nil {
+			return fmt.Errorf("%s: include %q: %w", name, pattern, err)
+		}
+		for _, includePath := range paths {
+			if err := l.loadPath(includePath, config); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// merge copies the settings and routes of one file into the merged config.
+// Every top-level setting other than include and routes may be set by
+// several files only if they agree.
+func (l *configLoader) merge(config, file *ServerConfig, path string) error {
+	dst := reflect.ValueOf(config).Elem()
+	src := reflect.ValueOf(file).Elem()
+	for name, field := range yamlFields(dst.Type()) {
+		if name == "include" || name == "routes" || name == "listeners" {
+			continue
+		}
+		srcValue := src.FieldByIndex(field.Index)
+		if srcValue.IsZero() {
+			continue
+		}
+		dstValue := dst.FieldByIndex(field.Index)
+		if !dstValue.IsZero() && !reflect.DeepEqual(dstValue.Interface(), srcValue.Interface()) {
+			return fmt.Errorf("%s: %s %s conflicts with %s set in %s",
+				path, name, describeSetting(srcValue), describeSetting(dstValue), l.settings[name])
+		}
+		dstValue.Set(srcValue)
+		l.settings[name] = path
+	}
+
+	config.Listeners = append(config.Listeners, file.Listeners...)
+	config.Routes = append(config.Routes, file.Routes...)
+	return nil
+}
+
+// describeSetting renders a setting value for conflict messages
+func describeSetting(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return fmt.Sprintf("%q", v.String())
+	}
+	return fmt.Sprintf("%+v", v.Interface())
+}
+
+// parseConfig strictly decodes a single YAML, JSON or TOML document and
+// records the file and line each route was declared at
+func parseConfig(data []byte, path, format string) (*ServerConfig, error) {
+	// Parse the content and reject unknown or mistyped keys with their position
+	document, err := parseDocument(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	var config ServerConfig
+	if err := checkKnownFields(document, reflect.TypeOf(config), ""); err != nil {
+		return nil, err
+	}
+
+	if err := document.Decode(&config); err != nil {
+		return nil, err
+	}
+
+	for i, line := range routeLines(document) {
+		if i >= len(config.Routes) {
+			break
+		}
+		if line > 0 {
+			config.Routes[i].source = fmt.Sprintf("%s:%d", path, line)
+		} else {
+			config.Routes[i].source = path
+		}
+	}
+
+	return &config, nil
+}
+
+// routeLines returns the line of every entry in the document's routes list
+func routeLines(document *yaml.Node) []int {
+	root := document
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "routes" {
+			continue
+		}
+		var lines []int
+		for _, item := range root.Content[i+1].Content {
+			lines = append(lines, item.Line)
+		}
+		return lines
+	}
+	return nil
+}
+
+// configFilesInDir lists the YAML, JSON and TOML files directly inside dir in lexical order
+func configFilesInDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, ok := formatExtensions[strings.ToLower(filepath.Ext(entry.Name()))]; ok {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+// Validate checks a configuration that was changed after it was loaded, and
+// fills in the defaults of the fields that were added
+func (s *ServerConfig) Validate() error {
+	if err := validateConfig(s); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
 // validateConfig validates the server configuration
 func validateConfig(config *ServerConfig) error {
-	if config.Address == "" {
-		config.Address = ":12330" // Set default address
+	// Listeners replace the top-level address, which must be checked before
+	// its default is filled in
+	if len(config.Listeners) > 0 && config.Address != "" {
+		return fmt.Errorf("address cannot be combined with listeners, set the address of each listener instead")
+	}
+
+	// Fill unset fields from their default tags
+	if err := applyDefaults(config); err != nil {
+		return err
+	}
+	if len(config.Listeners) > 0 {
+		// Keep the address unset so that the config validates again
+		config.Address = ""
+	}
+
+	if format := config.GetLogFormat(); format != LogFormatText && format != LogFormatJSON {
+		return fmt.Errorf("invalid log_format '%s', expected text or json", config.LogFormat)
+	}
+
+	for i, field := range config.AccessLog.Fields {
+		if !slices.Contains(AccessLogFields, field) {
+			return fmt.Errorf("access_log.fields[%d]: unknown field '%s', expected one of %s",
+				i, field, strings.Join(AccessLogFields, ", "))
+		}
+	}
+
+	for i, proxy := range config.TrustedProxies {
+		if _, err := ParsePrefix(proxy); err != nil {
+			return fmt.Errorf("trusted_proxies[%d]: %w", i, err)
+		}
+	}
+
+	if err := validateListeners(config); err != nil {
+		return err
+	}
+
+	if err := validateControls(&config.Controls); err != nil {
+		return fmt.Errorf("controls: %w", err)
 	}
 
 	// Validate routes
 	for i, route := range config.Routes {
 		if route.Path == "" {
-			return fmt.Errorf("route %d: path cannot be empty", i)
+			return fmt.Errorf("%s: path cannot be empty", route.describe(i))
 		}
 
 		// Validate HTTP method if provided
@@ -48,9 +321,304 @@ func validateConfig(config *ServerConfig) error {
 				"PATCH": true, "HEAD": true, "OPTIONS": true,
 			}
 			if !validMethods[route.Method] {
-				return fmt.Errorf("route %d: invalid HTTP method '%s'", i, route.Method)
+				return fmt.Errorf("%s: invalid HTTP method '%s'", route.describe(i), route.Method)
 			}
 		}
+
+		if route.Host != "" && !hostPatternRegexp.MatchString(strings.ToLower(route.Host)) {
+			return fmt.Errorf("%s: invalid host '%s', expected a hostname such as api.example.test or *.example.test", route.describe(i), route.Host)
+		}
+
+		for _, name := range route.Listeners {
+			if !slices.ContainsFunc(config.Listeners, func(l Listener) bool { return l.Name == name }) {
+				return fmt.Errorf("%s: unknown listener '%s'", route.describe(i), name)
+			}
+		}
+
+		if route.DumpFormat != "" && !slices.Contains(DumpFormats, route.GetDumpFormat()) {
+			return fmt.Errorf("%s: invalid dump_format '%s', expected json, yaml, http or html", route.describe(i), route.DumpFormat)
+		}
+
+		if err := validateEcho(route); err != nil {
+			return fmt.Errorf("%s: %w", route.describe(i), err)
+		}
+
+		if err := validateGenerate(route); err != nil {
+			return fmt.Errorf("%s: response_generate: %w", route.describe(i), err)
+		}
+
+		if err := validateCookies(route.ResponseCookies); err != nil {
+			return fmt.Errorf("%s: %w", route.describe(i), err)
+		}
+
+		for j, condition := range route.Conditions {
+			if err := validateClientIP(condition.ClientIP); err != nil {
+				return fmt.Errorf("%s, condition %d: %w", route.describe(i), j, err)
+			}
+			if err := validateCookies(condition.ResponseCookies); err != nil {
+				return fmt.Errorf("%s, condition %d: %w", route.describe(i), j, err)
+			}
+		}
+	}
+
+	// Every listener has a router per host, so routes only conflict with
+	// routes for the same host served on the same listener
+	hosts := routeHosts(config.Routes)
+	for _, listener := range config.GetListeners() {
+		for _, host := range hosts {
+			// Reject routes the router would refuse to register (and panic on)
+			if err := detectRouteConflicts(config.Routes, listener.Name, host); err != nil {
+				return err
+			}
+		}
+
+		// The metrics endpoint is registered with the routes for any host
+		if config.Metrics.Enabled {
+			if err := detectReservedConflict(config.Routes, listener.Name, "GET", config.Metrics.Path, "metrics.path"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if config.Tracing.Enabled && (config.Tracing.SampleRatio <= 0 || config.Tracing.SampleRatio > 1) {
+		return fmt.Errorf("tracing.sample_ratio must be greater than 0 and at most 1, got %g", config.Tracing.SampleRatio)
+	}
+
+	return nil
+}
+
+// routeHosts returns the distinct host patterns of routes, lowercased, with
+// the empty pattern of host-less routes first
+func routeHosts(routes []Route) []string {
+	hosts := []string{""}
+	for _, route := range routes {
+		host := strings.ToLower(route.Host)
+		if !slices.Contains(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// validateListeners checks that listener names and addresses are unique and
+// that each listener's TLS settings are valid
+func validateListeners(config *ServerConfig) error {
+	if len(config.Listeners) == 0 {
+		if err := validateAddress(config.Address); err != nil {
+			return fmt.Errorf("address: %w", err)
+		}
+		return validateTLS(&config.TLS, "tls")
+	}
+	if config.TLS.IsEnabled() {
+		return fmt.Errorf("tls cannot be combined with listeners, set the tls of each listener instead")
+	}
+	if config.ProxyProtocol {
+		return fmt.Errorf("proxy_protocol cannot be combined with listeners, set the proxy_protocol of each listener instead")
+	}
+
+	names := make(map[string]int)
+	addresses := make(map[string]int)
+	for i, listener := range config.Listeners {
+		if listener.Name == "" {
+			return fmt.Errorf("listeners[%d]: name cannot be empty", i)
+		}
+		if listener.Address == "" {
+			return fmt.Errorf("listeners[%d] (%s): address cannot be empty", i, listener.Name)
+		}
+		if err := validateAddress(listener.Address); err != nil {
+			return fmt.Errorf("listeners[%d] (%s): %w", i, listener.Name, err)
+		}
+		if first, ok := names[listener.Name]; ok {
+			return fmt.Errorf("listeners[%d]: name '%s' is already used by listeners[%d]", i, listener.Name, first)
+		}
+		if first, ok := addresses[listener.Address]; ok {
+			return fmt.Errorf("listeners[%d] (%s): address '%s' is already used by listeners[%d]", i, listener.Name, listener.Address, first)
+		}
+		names[listener.Name] = i
+		addresses[listener.Address] = i
+
+		if err := validateTLS(&listener.TLS, fmt.Sprintf("listeners[%d].tls", i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateControls checks the size limit of the request controls and that no
+// two controls read the same query parameter or header
+func validateControls(c *ControlsConfig) error {
+	if _, err := ParseSize(c.MaxSize); err != nil {
+		return fmt.Errorf("max_size: %w", err)
+	}
+
+	sources := c.sources()
+	names := slices.Sorted(maps.Keys(sources))
+	params := make(map[string]string)
+	headers := make(map[string]string)
+	for _, name := range names {
+		param, header := sources[name].GetNames(name)
+		if other, ok := params[param]; ok && param != "" {
+			return fmt.Errorf("%s and %s both read the query parameter '%s'", other, name, param)
+		}
+		if other, ok := headers[strings.ToLower(header)]; ok && header != "" {
+			return fmt.Errorf("%s and %s both read the header '%s'", other, name, header)
+		}
+		params[param] = name
+		headers[strings.ToLower(header)] = name
+	}
+	return nil
+}
+
+// validateClientIP checks that a client_ip condition has valid entries and
+// at least one of allow and deny
+func validateClientIP(m *ClientIPMatch) error {
+	if m == nil {
+		return nil
+	}
+	if len(m.Allow) == 0 && len(m.Deny) == 0 {
+		return fmt.Errorf("client_ip needs allow or deny")
+	}
+	allow, err := parsePrefixes(m.Allow, "client_ip.allow")
+	if err != nil {
+		return err
+	}
+	deny, err := parsePrefixes(m.Deny, "client_ip.deny")
+	if err != nil {
+		return err
+	}
+	m.allow, m.deny = allow, deny
+	return nil
+}
+
+// parsePrefixes parses a list of IPs and CIDRs, naming the entry at path on error
+func parsePrefixes(values []string, path string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for k, value := range values {
+		prefix, err := ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", path, k, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+// cookieNameRegexp matches a cookie name, which must be an HTTP token
+var cookieNameRegexp = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// validateEcho checks a route's response_echo settings
+func validateEcho(route Route) error {
+	if route.ResponseEcho.Enabled && route.ResponseDump {
+		return fmt.Errorf("response_echo cannot be combined with response_dump")
+	}
+	for k, operation := range route.ResponseEcho.Patch {
+		if err := operation.validate(); err != nil {
+			return fmt.Errorf("response_echo.patch[%d]: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// validateGenerate checks a route's response_generate settings
+func validateGenerate(route Route) error {
+	g := route.ResponseGenerate
+	if !g.IsEnabled() {
+		return nil
+	}
+	if route.ResponseDump || route.ResponseEcho.Enabled {
+		return fmt.Errorf("cannot be combined with response_dump or response_echo")
+	}
+
+	switch g.Type {
+	case GenerateRandom, GenerateText, GenerateLorem:
+		if g.Size == "" {
+			return fmt.Errorf("type %s needs a size", g.Type)
+		}
+		if _, err := ParseSize(g.Size); err != nil {
+			return fmt.Errorf("size: %w", err)
+		}
+	case GenerateJSON:
+		if g.Count <= 0 {
+			return fmt.Errorf("type json needs a positive count")
+		}
+		for name, fieldType := range g.Schema {
+			if !slices.Contains(FieldTypes, fieldType) {
+				return fmt.Errorf("schema.%s: invalid type '%s', expected one of %s", name, fieldType, strings.Join(FieldTypes, ", "))
+			}
+		}
+	default:
+		return fmt.Errorf("invalid type '%s', expected one of %s", g.Type, strings.Join(GenerateTypes, ", "))
+	}
+	return nil
+}
+
+// validateCookies checks the names, expiry and same_site of response cookies.
+// A cookie is identified by its name, path and domain, so only cookies that
+// share all three are duplicates.
+func validateCookies(cookies []Cookie) error {
+	for k, cookie := range cookies {
+		if !cookieNameRegexp.MatchString(cookie.Name) {
+			return fmt.Errorf("response_cookies[%d]: invalid cookie name '%s'", k, cookie.Name)
+		}
+		for l, earlier := range cookies[:k] {
+			if earlier.Name == cookie.Name && earlier.Path == cookie.Path && strings.EqualFold(earlier.Domain, cookie.Domain) {
+				return fmt.Errorf("response_cookies[%d] (%s): duplicate cookie, response_cookies[%d] has the same name, path and domain", k, cookie.Name, l)
+			}
+		}
+		if _, err := cookie.ExpiresAt(time.Now()); err != nil {
+			return fmt.Errorf("response_cookies[%d] (%s): %w", k, cookie.Name, err)
+		}
+		switch strings.ToLower(cookie.SameSite) {
+		case "", SameSiteLax, SameSiteStrict, SameSiteNone:
+		default:
+			return fmt.Errorf("response_cookies[%d] (%s): invalid same_site '%s', expected lax, strict or none", k, cookie.Name, cookie.SameSite)
+		}
+	}
+	return nil
+}
+
+// validateAddress checks that a listener address is a host:port, a unix
+// socket path or a systemd socket
+func validateAddress(address string) error {
+	switch {
+	case address == UnixAddressPrefix:
+		return fmt.Errorf("unix socket address '%s' needs a path, e.g. unix:/run/echo2.sock", address)
+	case strings.HasPrefix(address, UnixAddressPrefix), strings.HasPrefix(address, SystemdAddressPrefix):
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return fmt.Errorf("invalid address '%s', expected host:port, unix:/path.sock or systemd:[name]", address)
+	}
+	return nil
+}
+
+// validateTLS checks that the TLS settings at path describe one certificate
+// source and a client authentication mode that can be satisfied
+func validateTLS(t *TLSConfig, path string) error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("%s.cert_file and %s.key_file must be set together", path, path)
+	}
+	if t.SelfSigned && t.CertFile != "" {
+		return fmt.Errorf("%s.self_signed cannot be combined with %s.cert_file", path, path)
+	}
+
+	switch t.GetClientAuth() {
+	case ClientAuthNone, ClientAuthRequest, ClientAuthRequire:
+		if t.ClientCAFile != "" {
+			return fmt.Errorf("%s.client_ca_file requires %s.client_auth %s or %s", path, path, ClientAuthVerifyIfGiven, ClientAuthRequireAndVerify)
+		}
+	case ClientAuthVerifyIfGiven, ClientAuthRequireAndVerify:
+		if t.ClientCAFile == "" {
+			return fmt.Errorf("%s.client_auth %s requires %s.client_ca_file", path, t.GetClientAuth(), path)
+		}
+	default:
+		return fmt.Errorf("invalid %s.client_auth '%s', expected one of %s", path, t.ClientAuth,
+			strings.Join([]string{ClientAuthNone, ClientAuthRequest, ClientAuthRequire, ClientAuthVerifyIfGiven, ClientAuthRequireAndVerify}, ", "))
+	}
+
+	if !t.IsEnabled() && (t.GetClientAuth() != ClientAuthNone || len(t.SelfSignedHosts) > 0) {
+		return fmt.Errorf("%s settings require %s.cert_file or %s.self_signed", path, path, path)
 	}
 
 	return nil
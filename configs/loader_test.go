@@ -3,6 +3,7 @@ package configs
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -64,8 +65,8 @@ routes:
 		if route2.Method != "POST" {
 			t.Errorf("Expected method POST, got %s", route2.Method)
 		}
-		if route2.ResponseHeader["Content-Type"] != "application/json" {
-			t.Errorf("Expected Content-Type header application/json, got %s", route2.ResponseHeader["Content-Type"])
+		if got := route2.ResponseHeader["Content-Type"]; len(got) != 1 || got[0] != "application/json" {
+			t.Errorf("Expected Content-Type header application/json, got %v", got)
 		}
 	})
 
@@ -150,4 +151,613 @@ routes:
 			t.Error("Expected error for invalid HTTP method, got nil")
 		}
 	})
+
+	t.Run("conflicting routes", func(t *testing.T) {
+		configContent := `routes:
+  - path: "/api/{anything}"
+  - path: "/api/{id}"
+`
+		configFile := filepath.Join(tempDir, "conflicting_routes_config.yaml")
+		if err := os.WriteFile(configFile, []byte(configContent), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+
+		_, err := LoadConfig(configFile)
+		if err == nil {
+			t.Fatal("Expected error for conflicting routes, got nil")
+		}
+		if !strings.Contains(err.Error(), "route 1") || !strings.Contains(err.Error(), "route 0") {
+			t.Errorf("Expected error to name both routes, got %q", err.Error())
+		}
+	})
+	t.Run("unknown field", func(t *testing.T) {
+		configContent := `routes:
+  - path: "/test"
+    respone_body: "OK"
+`
+		configFile := filepath.Join(tempDir, "unknown_field_config.yaml")
+		if err := os.WriteFile(configFile, []byte(configContent), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+
+		_, err := LoadConfig(configFile)
+		if err == nil {
+			t.Fatal("Expected error for unknown field, got nil")
+		}
+		expected := `line 3, column 5: unknown field "respone_body" in routes[0] (did you mean "response_body"?)`
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q, got %q", expected, err.Error())
+		}
+	})
+
+	t.Run("logging options", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			content  string
+			expected string
+		}{
+			{"invalid log format", "log_format: xml\n", "invalid log_format 'xml'"},
+			{"unknown access log field", "access_log:\n  fields: [status, referer]\n", "access_log.fields[1]: unknown field 'referer'"},
+		}
+
+		for _, tt := range tests {
+			configFile := filepath.Join(tempDir, "logging_config.yaml")
+			content := tt.content + "routes:\n  - path: \"/test\"\n"
+			if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			_, err := LoadConfig(configFile)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.expected, err)
+			}
+		}
+
+		configFile := filepath.Join(tempDir, "logging_config.yaml")
+		content := "log_format: JSON\naccess_log:\n  enabled: true\nroutes:\n  - path: \"/test\"\n"
+		if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		config, err := LoadConfig(configFile)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.GetLogFormat() != "json" || config.AccessLog.MaxSizeMB != 100 || len(config.AccessLog.GetFields()) != len(AccessLogFields) {
+			t.Errorf("Expected json format and access log defaults, got %q %+v", config.GetLogFormat(), config.AccessLog)
+		}
+	})
+
+	t.Run("tls settings", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			content  string
+			expected string
+		}{
+			{"cert without key", "tls:\n  cert_file: server.pem\n", "tls.cert_file and tls.key_file must be set together"},
+			{"self-signed with cert", "tls:\n  self_signed: true\n  cert_file: server.pem\n  key_file: server.key\n", "tls.self_signed cannot be combined"},
+			{"invalid client auth", "tls:\n  self_signed: true\n  client_auth: always\n", "invalid tls.client_auth 'always'"},
+			{"verify without CA", "tls:\n  self_signed: true\n  client_auth: require_and_verify\n", "requires tls.client_ca_file"},
+			{"CA without verify", "tls:\n  self_signed: true\n  client_ca_file: ca.pem\n", "tls.client_ca_file requires tls.client_auth"},
+			{"client auth without TLS", "tls:\n  client_auth: request\n", "tls settings require tls.cert_file or tls.self_signed"},
+			{"valid mTLS", "tls:\n  self_signed: true\n  client_auth: VERIFY_IF_GIVEN\n  client_ca_file: ca.pem\n", ""},
+		}
+
+		for _, tt := range tests {
+			configFile := filepath.Join(tempDir, "tls_config.yaml")
+			content := tt.content + "routes:\n  - path: \"/test\"\n"
+			if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			_, err := LoadConfig(configFile)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("%s: expected no error, got %v", tt.name, err)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.expected, err)
+			}
+		}
+	})
+
+	t.Run("listeners", func(t *testing.T) {
+		listeners := "listeners:\n  - name: payments\n    address: \":9001\"\n  - name: users\n    address: \":9002\"\n"
+		tests := []struct {
+			name     string
+			content  string
+			expected string
+		}{
+			{"address with listeners", "address: \":8080\"\n" + listeners + "routes:\n  - path: \"/test\"\n", "address cannot be combined with listeners"},
+			{"tls with listeners", "tls:\n  self_signed: true\n" + listeners + "routes:\n  - path: \"/test\"\n", "tls cannot be combined with listeners"},
+			{"duplicate name", "listeners:\n  - name: a\n    address: \":9001\"\n  - name: a\n    address: \":9002\"\nroutes:\n  - path: \"/test\"\n", "listeners[1]: name 'a' is already used by listeners[0]"},
+			{"duplicate address", "listeners:\n  - name: a\n    address: \":9001\"\n  - name: b\n    address: \":9001\"\nroutes:\n  - path: \"/test\"\n", "listeners[1] (b): address ':9001' is already used"},
+			{"missing address", "listeners:\n  - name: a\nroutes:\n  - path: \"/test\"\n", "listeners[0] (a): address cannot be empty"},
+			{"listener tls", "listeners:\n  - name: a\n    address: \":9001\"\n    tls:\n      cert_file: a.pem\nroutes:\n  - path: \"/test\"\n", "listeners[0].tls.cert_file and listeners[0].tls.key_file"},
+			{"unknown listener", listeners + "routes:\n  - path: \"/test\"\n    listeners: [orders]\n", "route 0 (" + filepath.Join(tempDir, "listeners_config.yaml") + ":7): unknown listener 'orders'"},
+			{"conflict on a shared listener", listeners + "routes:\n  - path: \"/api/{id}\"\n    listeners: [payments]\n  - path: \"/api/{name}\"\n", "route 1"},
+			{"same path on different listeners", listeners + "routes:\n  - path: \"/api/{id}\"\n    listeners: [payments]\n  - path: \"/api/{name}\"\n    listeners: [users]\n", ""},
+			{"invalid address", "address: \"8080\"\nroutes:\n  - path: \"/test\"\n", "address: invalid address '8080'"},
+			{"unix socket without path", "listeners:\n  - name: a\n    address: \"unix:\"\nroutes:\n  - path: \"/test\"\n", "listeners[0] (a): unix socket address 'unix:' needs a path"},
+			{"unix and systemd sockets", "listeners:\n  - name: a\n    address: unix:/run/echo2.sock\n  - name: b\n    address: \"systemd:admin\"\nroutes:\n  - path: \"/test\"\n", ""},
+			{"proxy_protocol with listeners", "proxy_protocol: true\n" + listeners + "routes:\n  - path: \"/test\"\n", "proxy_protocol cannot be combined with listeners"},
+			{"listener proxy_protocol", "listeners:\n  - name: a\n    address: \":9001\"\n    proxy_protocol: true\nroutes:\n  - path: \"/test\"\n", ""},
+		}
+
+		for _, tt := range tests {
+			configFile := filepath.Join(tempDir, "listeners_config.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			_, err := LoadConfig(configFile)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("%s: expected no error, got %v", tt.name, err)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.expected, err)
+			}
+		}
+	})
+
+	t.Run("client addresses", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			content  string
+			expected string
+		}{
+			{"invalid trusted proxy", "trusted_proxies: [\"10.0.0.0/8\", \"10.0.0.0/33\"]\nroutes:\n  - path: \"/test\"\n", "trusted_proxies[1]: invalid CIDR '10.0.0.0/33'"},
+			{"trusted proxy address", "trusted_proxies: [\"192.0.2.1\", \"2001:db8::/32\"]\nroutes:\n  - path: \"/test\"\n", ""},
+			{"client_ip without allow or deny", "routes:\n  - path: \"/test\"\n    conditions:\n      - client_ip: {}\n", "condition 0: client_ip needs allow or deny"},
+			{"invalid client_ip", "routes:\n  - path: \"/test\"\n    conditions:\n      - header_match: {X-Test: \"1\"}\n      - client_ip:\n          deny: [\"10.0.0.0/8\", \"internal\"]\n", "condition 1: client_ip.deny[1]: invalid IP address 'internal'"},
+		}
+
+		for _, tt := range tests {
+			configFile := filepath.Join(tempDir, "client_ip_config.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			_, err := LoadConfig(configFile)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("%s: expected no error, got %v", tt.name, err)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.expected, err)
+			}
+		}
+	})
+
+	t.Run("cookies", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			content  string
+			expected string
+		}{
+			{"invalid cookie name", "routes:\n  - path: \"/test\"\n    response_cookies:\n      - name: \"my session\"\n", "response_cookies[0]: invalid cookie name 'my session'"},
+			{"invalid cookie expires", "routes:\n  - path: \"/test\"\n    conditions:\n      - cookie_match: {session: abc}\n        response_cookies:\n          - name: session\n            expires: tomorrow\n", "condition 0: response_cookies[0] (session): invalid expires 'tomorrow'"},
+			{"invalid cookie same_site", "routes:\n  - path: \"/test\"\n    response_cookies:\n      - name: session\n        same_site: relaxed\n", "invalid same_site 'relaxed'"},
+			{"duplicate cookie", "routes:\n  - path: \"/test\"\n    response_cookies:\n      - name: session\n        path: /a\n        domain: example.test\n      - name: session\n        path: /a\n        domain: Example.test\n", "response_cookies[1] (session): duplicate cookie, response_cookies[0] has the same name, path and domain"},
+			{"cookies sharing a name", "routes:\n  - path: \"/test\"\n    response_cookies:\n      - name: session\n        path: /a\n      - name: session\n        path: /b\n", ""},
+			{"valid cookies", "routes:\n  - path: \"/test\"\n    response_cookies:\n      - name: session\n        value: abc\n        expires: 24h\n        same_site: Strict\n      - name: __Host-id\n        max_age: -1\n", ""},
+		}
+
+		for _, tt := range tests {
+			configFile := filepath.Join(tempDir, "cookies_config.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			_, err := LoadConfig(configFile)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("%s: expected no error, got %v", tt.name, err)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.expected, err)
+			}
+		}
+	})
+
+	t.Run("dump format", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			content  string
+			expected string
+		}{
+			{"invalid dump_format", "routes:\n  - path: \"/test\"\n    response_dump: true\n    dump_format: xml\n", "invalid dump_format 'xml', expected json, yaml, http or html"},
+			{"dump_format", "routes:\n  - path: \"/test\"\n    response_dump: true\n    dump_format: HTML\n", ""},
+		}
+
+		for _, tt := range tests {
+			configFile := filepath.Join(tempDir, "dump_config.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			_, err := LoadConfig(configFile)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("%s: expected no error, got %v", tt.name, err)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.expected, err)
+			}
+		}
+	})
+
+	t.Run("controls", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			content  string
+			expected string
+		}{
+			{"invalid controls max_size", "controls:\n  max_size: lots\nroutes:\n  - path: \"/test\"\n", "controls: max_size: invalid size 'lots'"},
+			{"controls share a parameter", "controls:\n  enabled: true\n  status:\n    param: size\nroutes:\n  - path: \"/test\"\n", "controls: size and status both read the query parameter 'size'"},
+			{"controls share a header", "controls:\n  delay:\n    header: X-Echo-Control\n  status:\n    header: x-echo-control\nroutes:\n  - path: \"/test\"\n", "controls: delay and status both read the header 'x-echo-control'"},
+			{"renamed controls", "controls:\n  enabled: true\n  max_size: 1GB\n  delay:\n    param: sleep\n  status:\n    header: X-Echo-Status\n  header:\n    param: add_header\n    header: X-Echo-Header\nroutes:\n  - path: \"/test\"\n", ""},
+		}
+
+		for _, tt := range tests {
+			configFile := filepath.Join(tempDir, "controls_config.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			_, err := LoadConfig(configFile)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("%s: expected no error, got %v", tt.name, err)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.expected, err)
+			}
+		}
+	})
+
+	t.Run("response echo", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			content  string
+			expected string
+		}{
+			{"response_echo with response_dump", "routes:\n  - path: \"/test\"\n    response_dump: true\n    response_echo:\n      enabled: true\n", "response_echo cannot be combined with response_dump"},
+			{"invalid patch op", "routes:\n  - path: \"/test\"\n    response_echo:\n      enabled: true\n      patch:\n        - op: merge\n          path: /a\n", "response_echo.patch[0]: invalid op 'merge', expected add, remove, replace, move, copy or test"},
+			{"invalid patch path", "routes:\n  - path: \"/test\"\n    response_echo:\n      enabled: true\n      patch:\n        - op: remove\n          path: a\n", "response_echo.patch[0]: path: invalid JSON Pointer 'a', expected it to start with /"},
+			{"invalid patch from", "routes:\n  - path: \"/test\"\n    response_echo:\n      enabled: true\n      patch:\n        - op: copy\n          from: a/b\n          path: /a\n", "response_echo.patch[0]: from: invalid JSON Pointer 'a/b'"},
+			{"response_echo", "routes:\n  - path: \"/test\"\n    response_echo:\n      enabled: true\n      envelope: true\n      patch:\n        - op: add\n          path: /meta\n          value: {echoed: true, tags: [a, b]}\n        - op: move\n          from: /a\n          path: /b\n", ""},
+		}
+
+		for _, tt := range tests {
+			configFile := filepath.Join(tempDir, "echo_config.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			_, err := LoadConfig(configFile)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("%s: expected no error, got %v", tt.name, err)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.expected, err)
+			}
+		}
+	})
+
+	t.Run("response generate", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			content  string
+			expected string
+		}{
+			{"response_generate with response_echo", "routes:\n  - path: \"/test\"\n    response_echo:\n      enabled: true\n    response_generate:\n      type: lorem\n      size: 1KB\n", "response_generate: cannot be combined with response_dump or response_echo"},
+			{"invalid generate type", "routes:\n  - path: \"/test\"\n    response_generate:\n      type: xml\n", "response_generate: invalid type 'xml', expected one of random, text, lorem, json"},
+			{"generate without size", "routes:\n  - path: \"/test\"\n    response_generate:\n      type: random\n", "response_generate: type random needs a size"},
+			{"invalid generate size", "routes:\n  - path: \"/test\"\n    response_generate:\n      type: text\n      size: big\n", "response_generate: size: invalid size 'big'"},
+			{"generate json without count", "routes:\n  - path: \"/test\"\n    response_generate:\n      type: json\n", "response_generate: type json needs a positive count"},
+			{"invalid generate schema", "routes:\n  - path: \"/test\"\n    response_generate:\n      type: json\n      count: 5\n      schema: {id: index, price: decimal}\n", "response_generate: schema.price: invalid type 'decimal'"},
+			{"response_generate", "routes:\n  - path: \"/test\"\n    response_generate:\n      type: json\n      count: 5\n      schema: {id: uuid, price: float, created: time}\n  - path: \"/big\"\n    response_generate:\n      type: random\n      size: 100MB\n", ""},
+		}
+
+		for _, tt := range tests {
+			configFile := filepath.Join(tempDir, "generate_config.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			_, err := LoadConfig(configFile)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("%s: expected no error, got %v", tt.name, err)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.expected, err)
+			}
+		}
+	})
+
+	t.Run("hosts", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			content  string
+			expected string
+		}{
+			{"invalid host", "routes:\n  - path: \"/test\"\n    host: \"https://api.example.test\"\n", "invalid host 'https://api.example.test'"},
+			{"wildcard in the middle", "routes:\n  - path: \"/test\"\n    host: \"api.*.test\"\n", "invalid host 'api.*.test'"},
+			{"conflict on the same host", "routes:\n  - path: \"/api/{id}\"\n    host: api.example.test\n  - path: \"/api/{name}\"\n    host: API.example.test\n", "route 1"},
+			{"same path on different hosts", "routes:\n  - path: \"/api/{id}\"\n    host: api.example.test\n  - path: \"/api/{name}\"\n    host: \"*.partner.test\"\n  - path: \"/api/{other}\"\n", ""},
+		}
+
+		for _, tt := range tests {
+			configFile := filepath.Join(tempDir, "hosts_config.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			_, err := LoadConfig(configFile)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("%s: expected no error, got %v", tt.name, err)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.expected, err)
+			}
+		}
+	})
+
+	t.Run("tracing defaults and sample ratio", func(t *testing.T) {
+		configContent := `tracing:
+  enabled: true
+routes:
+  - path: "/test"
+`
+		configFile := filepath.Join(tempDir, "tracing_config.yaml")
+		if err := os.WriteFile(configFile, []byte(configContent), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+
+		config, err := LoadConfig(configFile)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.Tracing.Endpoint != "localhost:4318" || config.Tracing.ServiceName != "echo2" || config.Tracing.SampleRatio != 1 {
+			t.Errorf("Expected tracing defaults, got %+v", config.Tracing)
+		}
+
+		configContent = strings.Replace(configContent, "enabled: true", "enabled: true\n  sample_ratio: 1.5", 1)
+		if err := os.WriteFile(configFile, []byte(configContent), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+
+		_, err = LoadConfig(configFile)
+		if err == nil || !strings.Contains(err.Error(), "tracing.sample_ratio") {
+			t.Errorf("Expected sample ratio error, got %v", err)
+		}
+	})
+}
+
+func TestLoadConfigs(t *testing.T) {
+	// writeFiles creates the given files (relative path to content) in a fresh directory
+	writeFiles := func(t *testing.T, files map[string]string) string {
+		t.Helper()
+		dir := t.TempDir()
+		for name, content := range files {
+			path := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatalf("Failed to create directory: %v", err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+		}
+		return dir
+	}
+
+	routePaths := func(config *ServerConfig) []string {
+		var paths []string
+		for _, route := range config.Routes {
+			paths = append(paths, route.Path)
+		}
+		return paths
+	}
+
+	t.Run("includes with globs are merged in order", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml": `address: ":8080"
+include:
+  - "teams/*.yaml"
+  - "shared.yaml"
+routes:
+  - path: "/health"
+`,
+			"teams/b.yaml": `routes:
+  - path: "/b"
+`,
+			"teams/a.yaml": `routes:
+  - path: "/a"
+`,
+			"shared.yaml": `address: ":8080"
+routes:
+  - path: "/shared"
+`,
+		})
+
+		config, err := LoadConfigs(filepath.Join(dir, "config.yaml"))
+		if err != nil {
+			t.Fatalf("LoadConfigs() error = %v", err)
+		}
+
+		expected := "/health,/a,/b,/shared"
+		if got := strings.Join(routePaths(config), ","); got != expected {
+			t.Errorf("Expected routes %s, got %s", expected, got)
+		}
+		if config.Address != ":8080" {
+			t.Errorf("Expected address :8080, got %s", config.Address)
+		}
+
+		expectedSource := filepath.Join(dir, "teams/a.yaml") + ":2"
+		if config.Routes[1].Source() != expectedSource {
+			t.Errorf("Expected source %s, got %s", expectedSource, config.Routes[1].Source())
+		}
+	})
+
+	t.Run("listeners from several files are combined", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"payments.yaml": `listeners:
+  - name: payments
+    address: ":9001"
+routes:
+  - path: "/charge"
+    listeners: [payments]
+`,
+			"users.yaml": `listeners:
+  - name: users
+    address: ":9002"
+routes:
+  - path: "/users"
+    listeners: [users]
+`,
+		})
+
+		config, err := LoadConfigs(filepath.Join(dir, "payments.yaml"), filepath.Join(dir, "users.yaml"))
+		if err != nil {
+			t.Fatalf("LoadConfigs() error = %v", err)
+		}
+
+		listeners := config.GetListeners()
+		if len(listeners) != 2 || listeners[0].Name != "payments" || listeners[1].Name != "users" {
+			t.Errorf("Expected listeners payments and users, got %+v", listeners)
+		}
+	})
+
+	t.Run("directory and repeated paths", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"mocks/20-users.yaml": `routes:
+  - path: "/users"
+`,
+			"mocks/10-health.yml": `routes:
+  - path: "/health"
+`,
+			"mocks/README.md": `not a config`,
+			"extra.yaml": `routes:
+  - path: "/extra"
+`,
+		})
+
+		config, err := LoadConfigs(filepath.Join(dir, "mocks"), filepath.Join(dir, "extra.yaml"))
+		if err != nil {
+			t.Fatalf("LoadConfigs() error = %v", err)
+		}
+
+		expected := "/health,/users,/extra"
+		if got := strings.Join(routePaths(config), ","); got != expected {
+			t.Errorf("Expected routes %s, got %s", expected, got)
+		}
+	})
+
+	t.Run("file included twice is loaded once", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml": `include: ["a.yaml", "b.yaml"]
+`,
+			"a.yaml": `include: ["shared.yaml"]
+`,
+			"b.yaml": `include: ["shared.yaml"]
+`,
+			"shared.yaml": `routes:
+  - path: "/shared"
+`,
+		})
+
+		config, err := LoadConfigs(filepath.Join(dir, "config.yaml"))
+		if err != nil {
+			t.Fatalf("LoadConfigs() error = %v", err)
+		}
+		if len(config.Routes) != 1 {
+			t.Errorf("Expected 1 route, got %d", len(config.Routes))
+		}
+	})
+
+	tests := []struct {
+		name        string
+		files       map[string]string
+		expectedErr string
+	}{
+		{
+			name: "conflicting address",
+			files: map[string]string{
+				"config.yaml": `address: ":8080"
+include: ["other.yaml"]
+`,
+				"other.yaml": `address: ":9090"
+`,
+			},
+			expectedErr: `address ":9090" conflicts with ":8080" set in`,
+		},
+		{
+			name: "include cycle",
+			files: map[string]string{
+				"config.yaml": `include: ["other.yaml"]
+`,
+				"other.yaml": `include: ["config.yaml"]
+`,
+			},
+			expectedErr: "include cycle",
+		},
+		{
+			name: "missing include",
+			files: map[string]string{
+				"config.yaml": `include: ["missing.yaml"]
+`,
+			},
+			expectedErr: "failed to read config file",
+		},
+		{
+			name: "conflicting routes across files",
+			files: map[string]string{
+				"config.yaml": `include: ["team.yaml"]
+routes:
+  - path: "/health"
+`,
+				"team.yaml": `routes:
+  - path: "/other"
+  - path: "/health"
+`,
+			},
+			expectedErr: "team.yaml:3): GET /health conflicts with GET /health from route 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+
+			_, err := LoadConfigs(filepath.Join(dir, "config.yaml"))
+			if err == nil {
+				t.Fatalf("Expected error containing %q, got nil", tt.expectedErr)
+			}
+			if !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("Expected error containing %q, got %q", tt.expectedErr, err.Error())
+			}
+		})
+	}
 }
@@ -0,0 +1,176 @@
+package configs
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// hostPatternRegexp matches a valid route host: a hostname or IP address,
+// optionally prefixed with "*." to match its subdomains
+var hostPatternRegexp = regexp.MustCompile(`^(\*\.)?[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$|^\[?[0-9a-f:]+\]?$`)
+
+// MatchContext holds what conditions are matched against for a request
+type MatchContext struct {
+	Headers    map[string][]string // Request headers, with every value of repeated headers
+	Cookies    map[string]string   // Request cookies by name
+	ClientCert *ClientCertificate  // Client certificate of a TLS request, nil when none was presented
+	ClientIP   netip.Addr          // Client address, resolved through trusted proxies
+}
+
+// ClientCertificate describes the leaf certificate a TLS client presented
+type ClientCertificate struct {
+	Subject    string   `json:"subject"`        // Full subject, e.g. "CN=partner,O=Acme"
+	CommonName string   `json:"common_name"`    // Subject common name
+	SANs       []string `json:"sans,omitempty"` // DNS names, email addresses, IPs and URIs
+}
+
+// Matches reports whether every requirement of the condition holds for the
+// request described by m
+func (c *RouteCondition) Matches(m *MatchContext) bool {
+	return c.MatchesHeaderValues(m.Headers) && c.MatchesCookies(m.Cookies) &&
+		c.ClientCert.matches(m.ClientCert) && c.ClientIP.matches(m.ClientIP)
+}
+
+// matches reports whether cert satisfies the requirements. A nil match
+// accepts any request, with or without a certificate.
+func (m *ClientCertMatch) matches(cert *ClientCertificate) bool {
+	if m == nil {
+		return true
+	}
+	if cert == nil {
+		return false
+	}
+	if m.Subject != "" && m.Subject != cert.Subject {
+		return false
+	}
+	if m.CommonName != "" && m.CommonName != cert.CommonName {
+		return false
+	}
+	if m.SAN != "" {
+		for _, san := range cert.SANs {
+			if san == m.SAN {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// subsetOf reports whether every requirement of m is also required by other,
+// so any request other matches is matched by m too
+func (m *ClientCertMatch) subsetOf(other *ClientCertMatch) bool {
+	if m == nil {
+		return true
+	}
+	if other == nil {
+		return false
+	}
+	return (m.Subject == "" || m.Subject == other.Subject) &&
+		(m.CommonName == "" || m.CommonName == other.CommonName) &&
+		(m.SAN == "" || m.SAN == other.SAN)
+}
+
+// matches reports whether addr is allowed and not denied. A nil match
+// accepts any address. It uses the prefixes parsed by validateClientIP.
+func (m *ClientIPMatch) matches(addr netip.Addr) bool {
+	if m == nil {
+		return true
+	}
+	if len(m.allow) > 0 && !prefixesContain(m.allow, addr) {
+		return false
+	}
+	return !prefixesContain(m.deny, addr)
+}
+
+// subsetOf reports whether every address other accepts is accepted by m too:
+// other allows no more than m allows and denies at least what m denies
+func (m *ClientIPMatch) subsetOf(other *ClientIPMatch) bool {
+	if m == nil {
+		return true
+	}
+	if other == nil {
+		return false
+	}
+	if len(m.allow) > 0 {
+		if len(other.allow) == 0 {
+			return false
+		}
+		for _, allowed := range other.allow {
+			if !prefixesCover(m.allow, allowed) {
+				return false
+			}
+		}
+	}
+	for _, denied := range m.deny {
+		if !prefixesCover(other.deny, denied) {
+			return false
+		}
+	}
+	return true
+}
+
+// prefixesContain reports whether addr is in one of the prefixes
+func prefixesContain(prefixes []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixesCover reports whether inner lies entirely within one of the prefixes
+func prefixesCover(prefixes []netip.Prefix, inner netip.Prefix) bool {
+	for _, outer := range prefixes {
+		if outer.Bits() <= inner.Bits() && outer.Contains(inner.Addr()) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchHost reports whether a request host matches a route's host pattern.
+// Hosts compare case-insensitively and any port is ignored. A pattern
+// starting with "*." matches every subdomain of the rest of the pattern, at
+// any depth, but not the domain itself.
+func MatchHost(pattern, host string) bool {
+	pattern = strings.ToLower(pattern)
+	host = strings.ToLower(stripPort(host))
+
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return len(host) > len(suffix) && strings.HasSuffix(host, suffix)
+	}
+	return host == pattern
+}
+
+// stripPort removes a trailing :port from a Host header value, keeping IPv6
+// literals such as [::1] intact
+func stripPort(host string) string {
+	i := strings.LastIndexByte(host, ':')
+	if i < 0 || strings.HasSuffix(host, "]") || strings.Count(host, ":") > 1 && !strings.HasPrefix(host, "[") {
+		return host
+	}
+	return host[:i]
+}
+
+// ParsePrefix parses a CIDR such as 10.0.0.0/8, or a single IP address as a
+// prefix covering only that address
+func ParsePrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid CIDR '%s'", value)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address '%s'", value)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,191 @@
+package configs
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestRouteCondition_Matches(t *testing.T) {
+	cert := &ClientCertificate{
+		Subject:    "CN=partner-a,O=Acme",
+		CommonName: "partner-a",
+		SANs:       []string{"partner-a.example.com", "10.0.0.1"},
+	}
+
+	tests := []struct {
+		name      string
+		condition RouteCondition
+		match     MatchContext
+		expected  bool
+	}{
+		{
+			name:      "no requirements",
+			condition: RouteCondition{},
+			match:     MatchContext{},
+			expected:  true,
+		},
+		{
+			name:      "headers only",
+			condition: RouteCondition{HeaderMatch: map[string]string{"X-Test": "1"}},
+			match:     MatchContext{Headers: map[string][]string{"x-test": {"1"}}, ClientCert: cert},
+			expected:  true,
+		},
+		{
+			name:      "common name",
+			condition: RouteCondition{ClientCert: &ClientCertMatch{CommonName: "partner-a"}},
+			match:     MatchContext{ClientCert: cert},
+			expected:  true,
+		},
+		{
+			name:      "subject mismatch",
+			condition: RouteCondition{ClientCert: &ClientCertMatch{Subject: "CN=partner-a"}},
+			match:     MatchContext{ClientCert: cert},
+			expected:  false,
+		},
+		{
+			name:      "any SAN",
+			condition: RouteCondition{ClientCert: &ClientCertMatch{SAN: "10.0.0.1"}},
+			match:     MatchContext{ClientCert: cert},
+			expected:  true,
+		},
+		{
+			name:      "SAN not present",
+			condition: RouteCondition{ClientCert: &ClientCertMatch{SAN: "partner-b.example.com"}},
+			match:     MatchContext{ClientCert: cert},
+			expected:  false,
+		},
+		{
+			name:      "no client certificate",
+			condition: RouteCondition{ClientCert: &ClientCertMatch{}},
+			match:     MatchContext{},
+			expected:  false,
+		},
+		{
+			name: "certificate matches but headers do not",
+			condition: RouteCondition{
+				HeaderMatch: map[string]string{"X-Test": "1"},
+				ClientCert:  &ClientCertMatch{CommonName: "partner-a"},
+			},
+			match:    MatchContext{ClientCert: cert},
+			expected: false,
+		},
+		{
+			name:      "client ip allowed",
+			condition: RouteCondition{ClientIP: validClientIP([]string{"10.0.0.0/8", "192.0.2.1"}, nil)},
+			match:     MatchContext{ClientIP: netip.MustParseAddr("10.1.2.3")},
+			expected:  true,
+		},
+		{
+			name:      "client ip not allowed",
+			condition: RouteCondition{ClientIP: validClientIP([]string{"10.0.0.0/8", "192.0.2.1"}, nil)},
+			match:     MatchContext{ClientIP: netip.MustParseAddr("192.0.2.2")},
+			expected:  false,
+		},
+		{
+			name:      "client ip denied",
+			condition: RouteCondition{ClientIP: validClientIP([]string{"10.0.0.0/8"}, []string{"10.9.0.0/16"})},
+			match:     MatchContext{ClientIP: netip.MustParseAddr("10.9.1.1")},
+			expected:  false,
+		},
+		{
+			name:      "deny only",
+			condition: RouteCondition{ClientIP: validClientIP(nil, []string{"2001:db8::/32"})},
+			match:     MatchContext{ClientIP: netip.MustParseAddr("::ffff:203.0.113.5")},
+			expected:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.condition.Matches(&tt.match); got != tt.expected {
+				t.Errorf("RouteCondition.Matches() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClientIPMatch_subsetOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		m, other *ClientIPMatch
+		expected bool
+	}{
+		{"nil accepts everything", nil, validClientIP([]string{"10.0.0.0/8"}, nil), true},
+		{"narrower allow", validClientIP([]string{"10.0.0.0/8"}, nil), validClientIP([]string{"10.1.0.0/16", "10.2.3.4"}, nil), true},
+		{"wider allow", validClientIP([]string{"10.1.0.0/16"}, nil), validClientIP([]string{"10.0.0.0/8"}, nil), false},
+		{"other allows everything", validClientIP([]string{"10.0.0.0/8"}, nil), validClientIP(nil, []string{"192.0.2.0/24"}), false},
+		{"other denies more", validClientIP(nil, []string{"192.0.2.0/25"}), validClientIP(nil, []string{"192.0.2.0/24"}), true},
+		{"other denies less", validClientIP(nil, []string{"192.0.2.0/24"}), validClientIP(nil, []string{"192.0.2.0/25"}), false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.subsetOf(tt.other); got != tt.expected {
+			t.Errorf("%s: subsetOf() = %v, want %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		host     string
+		expected bool
+	}{
+		{"api.example.test", "api.example.test", true},
+		{"api.example.test", "API.Example.Test:8080", true},
+		{"api.example.test", "www.example.test", false},
+		{"*.partner.test", "a.partner.test", true},
+		{"*.partner.test", "a.b.partner.test:443", true},
+		{"*.partner.test", "partner.test", false},
+		{"*.partner.test", "evilpartner.test", false},
+		{"[::1]", "[::1]:12330", true},
+		{"127.0.0.1", "127.0.0.1:12330", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.host, func(t *testing.T) {
+			if got := MatchHost(tt.pattern, tt.host); got != tt.expected {
+				t.Errorf("MatchHost(%q, %q) = %v, want %v", tt.pattern, tt.host, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsePrefix(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+		err      bool
+	}{
+		{"10.0.0.0/8", "10.0.0.0/8", false},
+		{"10.1.2.3/8", "10.0.0.0/8", false},
+		{"192.0.2.1", "192.0.2.1/32", false},
+		{"::ffff:192.0.2.1", "192.0.2.1/32", false},
+		{"2001:db8::/32", "2001:db8::/32", false},
+		{"10.0.0.0/33", "", true},
+		{"example.test", "", true},
+	}
+
+	for _, tt := range tests {
+		prefix, err := ParsePrefix(tt.value)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParsePrefix(%q): expected an error, got %s", tt.value, prefix)
+			}
+			continue
+		}
+		if err != nil || prefix.String() != tt.expected {
+			t.Errorf("ParsePrefix(%q) = %s, %v, expected %s", tt.value, prefix, err, tt.expected)
+		}
+	}
+}
+
+// validClientIP returns a client_ip match with its prefixes parsed, like a
+// loaded config's
+func validClientIP(allow, deny []string) *ClientIPMatch {
+	m := &ClientIPMatch{Allow: allow, Deny: deny}
+	if err := validateClientIP(m); err != nil {
+		panic(err)
+	}
+	return m
+}
@@ -0,0 +1,88 @@
+package configs
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// JSONSchema returns a JSON Schema (draft-07) describing the configuration
+// file, generated from the yaml, default and required tags of ServerConfig.
+// Editors can use it to autocomplete and validate config.yaml.
+func JSONSchema() ([]byte, error) {
+	schema := schemaFor(reflect.TypeOf(ServerConfig{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "echo2 server configuration"
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// schemaFor builds the schema of a single Go type
+func schemaFor(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == durationType {
+		return map[string]any{"type": "string", "pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`}
+	}
+	if t == headerValuesType {
+		return map[string]any{"oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		}}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]any)
+		var required []string
+		for name, field := range yamlFields(t) {
+			property := schemaFor(field.Type)
+			if tag, ok := field.Tag.Lookup("default"); ok {
+				property["default"] = schemaDefault(field.Type, tag)
+			}
+			properties[name] = property
+			if field.Tag.Get("required") == "true" {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if len(required) > 0 {
+			sort.Strings(required)
+			schema["required"] = required
+		}
+		return schema
+	}
+
+	// Anything else (interfaces) accepts any value
+	return map[string]any{}
+}
+
+// schemaDefault converts a default tag into a JSON value of the field's type
+func schemaDefault(t reflect.Type, tag string) any {
+	if t == durationType {
+		return tag
+	}
+	value := reflect.New(t).Elem()
+	if err := setFromString(value, tag); err != nil {
+		return tag
+	}
+	return value.Interface()
+}
@@ -0,0 +1,65 @@
+package configs
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+
+	var schema struct {
+		Schema               string `json:"$schema"`
+		AdditionalProperties bool   `json:"additionalProperties"`
+		Properties           map[string]struct {
+			Type    string `json:"type"`
+			Default any    `json:"default"`
+			Items   struct {
+				Required   []string `json:"required"`
+				Properties map[string]struct {
+					Type                 string `json:"type"`
+					Default              any    `json:"default"`
+					AdditionalProperties any    `json:"additionalProperties"`
+				} `json:"properties"`
+			} `json:"items"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+
+	if schema.Schema != "http://json-schema.org/draft-07/schema#" {
+		t.Errorf("Expected draft-07 $schema, got %q", schema.Schema)
+	}
+	if schema.AdditionalProperties {
+		t.Error("Expected unknown top level fields to be rejected")
+	}
+	if got := schema.Properties["address"].Default; got != ":12330" {
+		t.Errorf("Expected address default :12330, got %v", got)
+	}
+	if got := schema.Properties["log_level"].Default; got != "info" {
+		t.Errorf("Expected log_level default info, got %v", got)
+	}
+
+	routes := schema.Properties["routes"]
+	if routes.Type != "array" {
+		t.Errorf("Expected routes to be an array, got %q", routes.Type)
+	}
+	if len(routes.Items.Required) != 1 || routes.Items.Required[0] != "path" {
+		t.Errorf("Expected routes to require path, got %v", routes.Items.Required)
+	}
+	if got := routes.Items.Properties["response_status"]; got.Type != "integer" || got.Default != float64(200) {
+		t.Errorf("Expected integer response_status defaulting to 200, got %+v", got)
+	}
+	if got := routes.Items.Properties["method"].Default; got != "GET" {
+		t.Errorf("Expected method default GET, got %v", got)
+	}
+	// Header values are a single string or a list of strings
+	headerValues, _ := routes.Items.Properties["response_header"].AdditionalProperties.(map[string]any)
+	if oneOf, _ := headerValues["oneOf"].([]any); len(oneOf) != 2 {
+		t.Errorf("Expected response_header values to be a string or an array, got %v", headerValues)
+	}
+}
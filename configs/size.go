@@ -0,0 +1,45 @@
+package configs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits maps the accepted size suffixes to their number of bytes. KB, MB
+// and GB are binary units, so 1MB is 1048576 bytes like 1MiB.
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+}
+
+// maxSize is the largest size ParseSize accepts, well below the sizes that
+// would overflow an int64
+const maxSize = 1 << 40
+
+// ParseSize parses a byte size such as 512, 64KB, 1.5MB or 2GiB, up to 1TiB.
+// Units are case-insensitive.
+func ParseSize(value string) (int64, error) {
+	s := strings.TrimSpace(value)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	number, err := strconv.ParseFloat(s[:i], 64)
+	if !ok || err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size '%s', expected bytes or a size such as 512KB or 10MB", value)
+	}
+	size := number * float64(unit)
+	if size > maxSize {
+		return 0, fmt.Errorf("size '%s' exceeds the maximum of 1TiB", value)
+	}
+	return int64(size), nil
+}
@@ -0,0 +1,46 @@
+package configs
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int64
+		valid    bool
+	}{
+		{"0", 0, true},
+		{"512", 512, true},
+		{"100B", 100, true},
+		{"64KB", 64 << 10, true},
+		{"64kib", 64 << 10, true},
+		{"1MB", 1 << 20, true},
+		{"1.5 MB", 3 << 19, true},
+		{"2GiB", 2 << 30, true},
+		{"1024GB", 1 << 40, true},
+		{"1025GB", 0, false},
+		{"99999999999999999999GB", 0, false},
+		{"", 0, false},
+		{"MB", 0, false},
+		{"10TB", 0, false},
+		{"-1KB", 0, false},
+		{"1.2.3KB", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseSize(tt.value)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("Expected an error for %q, got %d", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %d bytes, got %d", tt.expected, got)
+			}
+		})
+	}
+}
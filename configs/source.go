@@ -0,0 +1,171 @@
+package configs
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StdinPath is the config path that reads the configuration from standard input
+const StdinPath = "-"
+
+// stdinName is used for the standard input in error messages and route sources
+const stdinName = "<stdin>"
+
+// maxRemoteConfigSize bounds how much of a remote config is read
+const maxRemoteConfigSize = 10 << 20
+
+// stdin is read when a config path is StdinPath
+var stdin io.Reader = os.Stdin
+
+// httpClient fetches configs given as http:// or https:// URLs
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// IsRemote reports whether a config path is an http:// or https:// URL
+func IsRemote(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// sourceKey identifies a config source so that each one is loaded only once
+func sourceKey(path string) (string, error) {
+	if path == StdinPath || IsRemote(path) {
+		return path, nil
+	}
+	return filepath.Abs(path)
+}
+
+// sourceName is how a config source is named in errors and route sources
+func sourceName(path string) string {
+	if path == StdinPath {
+		return stdinName
+	}
+	return path
+}
+
+// sourceDir is the directory relative ${file:...} references and includes are
+// resolved against: the config file's directory, or the working directory
+// for standard input
+func sourceDir(path string) string {
+	if path == StdinPath || IsRemote(path) {
+		return "."
+	}
+	return filepath.Dir(path)
+}
+
+// readSource returns the content of a config file, standard input or URL
+// together with its detected format
+func readSource(path string) ([]byte, string, error) {
+	switch {
+	case path == StdinPath:
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read config from stdin: %w", err)
+		}
+		return data, detectFormat("", data), nil
+	case IsRemote(path):
+		return fetchConfig(path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config file: %w", err)
+	}
+	return data, detectFormat(path, data), nil
+}
+
+// fetchConfig downloads a remote config. The format comes from the URL's
+// extension, then the Content-Type header, then the content itself.
+func fetchConfig(rawURL string) ([]byte, string, error) {
+	resp, err := httpClient.Get(rawURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch config: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, "", fmt.Errorf("failed to fetch config %s: unexpected status %s", rawURL, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfigSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch config %s: %w", rawURL, err)
+	}
+	if len(data) > maxRemoteConfigSize {
+		return nil, "", fmt.Errorf("failed to fetch config %s: larger than %d bytes", rawURL, maxRemoteConfigSize)
+	}
+
+	urlPath := resp.Request.URL.Path
+	if _, ok := formatExtensions[strings.ToLower(path.Ext(urlPath))]; !ok {
+		if format := formatFromContentType(resp.Header.Get("Content-Type")); format != "" {
+			return data, format, nil
+		}
+	}
+	return data, detectFormat(urlPath, data), nil
+}
+
+// formatFromContentType maps a Content-Type header to a config format, or
+// returns an empty string when it does not name one
+func formatFromContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return FormatJSON
+	case strings.HasSuffix(mediaType, "toml"):
+		return FormatTOML
+	case strings.HasSuffix(mediaType, "yaml"):
+		return FormatYAML
+	}
+	return ""
+}
+
+// resolveInclude expands an include entry of the config at from. Local
+// includes are relative to the including file's directory (or the working
+// directory for standard input) and may be globs that match nothing, but a
+// plain path must exist. Includes of a remote config are resolved against
+// its URL and cannot be globs.
+func resolveInclude(from, pattern string) ([]string, error) {
+	if IsRemote(pattern) {
+		return []string{pattern}, nil
+	}
+
+	if IsRemote(from) {
+		if strings.ContainsAny(pattern, "*?[") {
+			return nil, fmt.Errorf("glob patterns are not supported in remote configs")
+		}
+		base, err := url.Parse(from)
+		if err != nil {
+			return nil, err
+		}
+		ref, err := url.Parse(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return []string{base.ResolveReference(ref).String()}, nil
+	}
+
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(sourceDir(from), pattern)
+	}
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		return []string{pattern}, nil
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
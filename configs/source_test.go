@@ -0,0 +1,139 @@
+package configs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig_Stdin(t *testing.T) {
+	originalStdin := stdin
+	defer func() { stdin = originalStdin }()
+
+	stdin = strings.NewReader(`address: ":8080"
+routes:
+  - path: "/health"
+    response_body: "OK"
+`)
+
+	config, err := LoadConfigs(StdinPath, StdinPath)
+	if err != nil {
+		t.Fatalf("LoadConfigs() error = %v", err)
+	}
+	if config.Address != ":8080" {
+		t.Errorf("Expected address :8080, got %s", config.Address)
+	}
+	if len(config.Routes) != 1 {
+		t.Fatalf("Expected 1 route, got %d", len(config.Routes))
+	}
+	if config.Routes[0].Source() != "<stdin>:3" {
+		t.Errorf("Expected source <stdin>:3, got %s", config.Routes[0].Source())
+	}
+
+	t.Run("errors name stdin", func(t *testing.T) {
+		stdin = strings.NewReader(`{"routes": [{"path": "/x", "respone_body": "OK"}]}`)
+
+		_, err := LoadConfig(StdinPath)
+		expected := `failed to unmarshal config <stdin>: line 1, column 28: unknown field "respone_body"`
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q, got %v", expected, err)
+		}
+	})
+}
+
+func TestLoadConfig_Remote(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mocks/config", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"address": ":8080", "include": ["team.toml"], "routes": [{"path": "/health"}]}`))
+	})
+	mux.HandleFunc("/mocks/team.toml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[[routes]]\npath = \"/team\"\n"))
+	})
+	mux.HandleFunc("/mocks/invalid.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("routes:\n  - path: \"/x\"\n    method: \"FETCH\"\n"))
+	})
+	var body string
+	mux.HandleFunc("/mocks/leak.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Run("fetches config and relative includes", func(t *testing.T) {
+		config, err := LoadConfig(server.URL + "/mocks/config")
+		if err != nil {
+			t.Fatalf("LoadConfig() error = %v", err)
+		}
+		if config.Address != ":8080" {
+			t.Errorf("Expected address :8080, got %s", config.Address)
+		}
+		if len(config.Routes) != 2 || config.Routes[1].Path != "/team" {
+			t.Fatalf("Expected routes /health and /team, got %+v", config.Routes)
+		}
+		if config.Routes[1].Source() != server.URL+"/mocks/team.toml" {
+			t.Errorf("Expected source %s, got %s", server.URL+"/mocks/team.toml", config.Routes[1].Source())
+		}
+	})
+
+	t.Run("validates remote config", func(t *testing.T) {
+		_, err := LoadConfig(server.URL + "/mocks/invalid.yaml")
+		if err == nil || !strings.Contains(err.Error(), "invalid HTTP method 'FETCH'") {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("remote config cannot reference local files or the environment", func(t *testing.T) {
+		secret := filepath.Join(t.TempDir(), "secret")
+		if err := os.WriteFile(secret, []byte("hunter2"), 0600); err != nil {
+			t.Fatalf("Failed to write secret: %v", err)
+		}
+		t.Setenv("ECHO2_SECRET", "hunter2")
+		for _, reference := range []string{"${file:" + secret + "}", "${ECHO2_SECRET}", "${ECHO2_SECRET:-x}"} {
+			body = "routes:\n  - path: \"/leak\"\n    response_body: \"" + reference + "\"\n"
+			config, err := LoadConfig(server.URL + "/mocks/leak.yaml")
+			if err == nil || !strings.Contains(err.Error(), "remote configs cannot reference files or environment variables") {
+				t.Errorf("%s: expected the reference to be rejected, got %v (%+v)", reference, err, config)
+			}
+		}
+
+		body = "routes:\n  - path: \"/template\"\n    response_body: \"$${name}\"\n"
+		config, err := LoadConfig(server.URL + "/mocks/leak.yaml")
+		if err != nil || config.Routes[0].ResponseBody != "${name}" {
+			t.Errorf("Expected escapes to expand, got %v (%+v)", err, config)
+		}
+	})
+
+	t.Run("unexpected status", func(t *testing.T) {
+		_, err := LoadConfig(server.URL + "/missing.yaml")
+		if err == nil || !strings.Contains(err.Error(), "unexpected status 404") {
+			t.Errorf("Expected status error, got %v", err)
+		}
+	})
+}
+
+func TestFormatFromContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		expected    string
+	}{
+		{"application/json; charset=utf-8", FormatJSON},
+		{"application/vnd.mocks+json", FormatJSON},
+		{"application/toml", FormatTOML},
+		{"application/yaml", FormatYAML},
+		{"text/x-yaml", FormatYAML},
+		{"text/plain", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			if got := formatFromContentType(tt.contentType); got != tt.expected {
+				t.Errorf("formatFromContentType(%q) = %q, want %q", tt.contentType, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// durationType is decoded by yaml.v3 from strings such as "10s"
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// headerValuesType is decoded from a single string or a list of strings
+var headerValuesType = reflect.TypeOf(HeaderValues{})
+
+// checkKnownFields walks a parsed YAML document alongside the Go type it will
+// be decoded into and reports the first unknown key or mistyped scalar. Unlike
+// yaml.v3's own KnownFields errors, the returned error carries the line,
+// column and the position inside the config (e.g. routes[2].conditions[0]).
+func checkKnownFields(node *yaml.Node, t reflect.Type, path string) error {
+	if node == nil || node.Kind == 0 {
+		return nil
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := checkKnownFields(child, t, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.AliasNode:
+		return checkKnownFields(node.Alias, t, path)
+	}
+
+	// Null values are valid for every field and leave the zero value in place
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null" {
+		return nil
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == durationType {
+		return expectKind(node, yaml.ScalarNode, "a duration", path)
+	}
+	if t == headerValuesType && node.Kind == yaml.ScalarNode {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if err := expectKind(node, yaml.MappingNode, "a mapping", path); err != nil {
+			return err
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				// Merge keys pull in the fields of another mapping
+				if err := checkKnownFields(value, t, path); err != nil {
+					return err
+				}
+				continue
+			}
+			field, ok := fields[key.Value]
+			if !ok {
+				return unknownFieldError(key, path, fields)
+			}
+			if err := checkKnownFields(value, field.Type, joinPath(path, key.Value)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		if err := expectKind(node, yaml.SequenceNode, "a list", path); err != nil {
+			return err
+		}
+		for i, item := range node.Content {
+			if err := checkKnownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if err := expectKind(node, yaml.MappingNode, "a mapping", path); err != nil {
+			return err
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := checkKnownFields(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value)); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		return expectKind(node, yaml.ScalarNode, "a string", path)
+	case reflect.Bool:
+		return expectTag(node, "!!bool", "a boolean", path)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return expectTag(node, "!!int", "an integer", path)
+	case reflect.Float32, reflect.Float64:
+		if node.ShortTag() == "!!int" {
+			return nil
+		}
+		return expectTag(node, "!!float", "a number", path)
+	}
+
+	return nil
+}
+
+// yamlFields maps the YAML key of every exported struct field to the field
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// expectKind reports an error when node is not of the wanted YAML kind
+func expectKind(node *yaml.Node, kind yaml.Kind, want, path string) error {
+	if node.Kind == kind {
+		return nil
+	}
+	return fmt.Errorf("%s%s: expected %s, got %s",
+		describePosition(node), describePath(path), want, describeNode(node))
+}
+
+// expectTag reports an error when node is not a scalar with the wanted tag
+func expectTag(node *yaml.Node, tag, want, path string) error {
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == tag {
+		return nil
+	}
+	return fmt.Errorf("%s%s: expected %s, got %s",
+		describePosition(node), describePath(path), want, describeNode(node))
+}
+
+// unknownFieldError builds the error for a key that has no matching field,
+// suggesting the closest known key when one is similar enough
+func unknownFieldError(key *yaml.Node, path string, fields map[string]reflect.StructField) error {
+	msg := fmt.Sprintf("%sunknown field %q in %s",
+		describePosition(key), key.Value, describePath(path))
+
+	best, bestDistance := "", -1
+	for name := range fields {
+		d := levenshtein(strings.ToLower(key.Value), name)
+		if bestDistance < 0 || d < bestDistance || (d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	if best != "" && bestDistance <= max(2, len(best)/3) {
+		msg += fmt.Sprintf(" (did you mean %q?)", best)
+	}
+
+	return errors.New(msg)
+}
+
+// describePosition renders the line and column of a node as an error prefix,
+// or nothing for nodes without a position
+func describePosition(node *yaml.Node) string {
+	if node.Line == 0 {
+		return ""
+	}
+	return fmt.Sprintf("line %d, column %d: ", node.Line, node.Column)
+}
+
+// describeNode renders a node for error messages, e.g. !!str "abc"
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	return fmt.Sprintf("%s %q", node.ShortTag(), node.Value)
+}
+
+// describePath names a position in the config for error messages
+func describePath(path string) string {
+	if path == "" {
+		return "top level"
+	}
+	return path
+}
+
+// joinPath appends a mapping key to a config path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
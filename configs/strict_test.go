@@ -0,0 +1,112 @@
+package configs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCheckKnownFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectedErr string
+	}{
+		{
+			name: "all fields known",
+			content: `address: ":8080"
+routes:
+  - path: "/health"
+    response_status: 200
+    response_dump: true
+    response_header:
+      Content-Type: "text/plain"
+    conditions:
+      - header_match:
+          X-Test: "1"
+`,
+		},
+		{
+			name:    "empty document",
+			content: ``,
+		},
+		{
+			name: "misspelled route field",
+			content: `routes:
+  - path: "/health"
+  - path: "/users"
+    respone_body: "OK"
+`,
+			expectedErr: `line 4, column 5: unknown field "respone_body" in routes[1] (did you mean "response_body"?)`,
+		},
+		{
+			name: "misspelled condition field",
+			content: `routes:
+  - path: "/secure"
+    conditions:
+      - header_macth:
+          Authorization: "Bearer token"
+`,
+			expectedErr: `line 4, column 9: unknown field "header_macth" in routes[0].conditions[0] (did you mean "header_match"?)`,
+		},
+		{
+			name: "unknown top level field without suggestion",
+			content: `address: ":8080"
+something_else: true
+`,
+			expectedErr: `line 2, column 1: unknown field "something_else" in top level`,
+		},
+		{
+			name: "mistyped status",
+			content: `routes:
+  - path: "/health"
+    response_status: "ok"
+`,
+			expectedErr: `line 3, column 22: routes[0].response_status: expected an integer, got !!str "ok"`,
+		},
+		{
+			name: "routes is not a list",
+			content: `routes:
+  path: "/health"
+`,
+			expectedErr: `line 2, column 3: routes: expected a list, got a mapping`,
+		},
+		{
+			name: "anchors and merge keys",
+			content: `routes:
+  - &health
+    path: "/health"
+    response_body: "OK"
+  - <<: *health
+    method: "HEAD"
+    respnse_status: 204
+`,
+			expectedErr: `line 7, column 5: unknown field "respnse_status" in routes[1] (did you mean "response_status"?)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var document yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.content), &document); err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			err := checkKnownFields(&document, reflect.TypeOf(ServerConfig{}), "")
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected error containing %q, got nil", tt.expectedErr)
+			}
+			if !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("Expected error containing %q, got %q", tt.expectedErr, err.Error())
+			}
+		})
+	}
+}
@@ -1,61 +1,468 @@
 package configs
 
 import (
+	"fmt"
 	"net/http"
+	"net/netip"
+	"slices"
 	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // ServerConfig contains server configuration
 type ServerConfig struct {
-	Address  string  `yaml:"address" default:":12330"`
-	LogLevel string  `yaml:"log_level" default:"info"`
-	Routes   []Route `yaml:"routes"`
+	Address        string          `yaml:"address" default:":12330"`
+	LogLevel       string          `yaml:"log_level" default:"info"`
+	LogFormat      string          `yaml:"log_format,omitempty" default:"text"`
+	AccessLog      AccessLogConfig `yaml:"access_log,omitempty"`
+	RequestID      RequestIDConfig `yaml:"request_id,omitempty"`
+	TLS            TLSConfig       `yaml:"tls,omitempty"`
+	ProxyProtocol  bool            `yaml:"proxy_protocol,omitempty"`  // Expect a PROXY protocol header on every connection
+	TrustedProxies []string        `yaml:"trusted_proxies,omitempty"` // IPs and CIDRs allowed to set X-Forwarded-For and Forwarded
+	Listeners      []Listener      `yaml:"listeners,omitempty"`
+	Include        []string        `yaml:"include,omitempty"`
+	Metrics        MetricsConfig   `yaml:"metrics,omitempty"`
+	Tracing        TracingConfig   `yaml:"tracing,omitempty"`
+	Controls       ControlsConfig  `yaml:"controls,omitempty"`
+	Routes         []Route         `yaml:"routes"`
+}
+
+// Address prefixes for listeners that do not listen on TCP. Any other
+// address is a TCP host:port.
+const (
+	UnixAddressPrefix    = "unix:"    // unix:/path/to/echo2.sock listens on a unix domain socket
+	SystemdAddressPrefix = "systemd:" // systemd: or systemd:<name> inherits a socket through LISTEN_FDS
+)
+
+// Log formats accepted by log_format
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Request dump formats accepted by dump_format
+const (
+	DumpFormatJSON = "json"
+	DumpFormatYAML = "yaml"
+	DumpFormatHTTP = "http" // The request in HTTP/1.1 wire format
+	DumpFormatHTML = "html"
+)
+
+// DumpFormats lists the formats a request dump can be rendered in
+var DumpFormats = []string{DumpFormatJSON, DumpFormatYAML, DumpFormatHTTP, DumpFormatHTML}
+
+// AccessLogFields are the optional fields of an access log entry. The
+// method and path are always logged; route and condition are left out when
+// no route matched.
+var AccessLogFields = []string{"route", "status", "latency", "bytes", "client_ip", "user_agent", "condition"}
+
+// AccessLogConfig controls the access log, which records one entry per
+// request regardless of log_level, including 404 and 405 responses and the
+// metrics endpoint. Entries go to standard output, or to File with
+// size-based rotation.
+type AccessLogConfig struct {
+	Enabled    bool     `yaml:"enabled,omitempty"`
+	Fields     []string `yaml:"fields,omitempty"` // Subset of AccessLogFields, all of them when empty
+	File       string   `yaml:"file,omitempty"`
+	MaxSizeMB  int      `yaml:"max_size_mb,omitempty" default:"100"`
+	MaxBackups int      `yaml:"max_backups,omitempty"`  // Rotated files to keep, 0 keeps all
+	MaxAgeDays int      `yaml:"max_age_days,omitempty"` // Days to keep rotated files, 0 keeps them forever
+	Compress   bool     `yaml:"compress,omitempty"`     // Gzip rotated files
+}
+
+// GetFields returns the access log fields to record, defaulting to all of them
+func (a *AccessLogConfig) GetFields() []string {
+	if len(a.Fields) == 0 {
+		return AccessLogFields
+	}
+	return a.Fields
+}
+
+// MetricsConfig controls the Prometheus metrics endpoint
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled,omitempty"`
+	Path    string `yaml:"path,omitempty" default:"/metrics"`
+}
+
+// Listener is an address echo2 serves routes on. When listeners are
+// configured they replace the top-level address and tls settings, and each
+// route is served on the listeners it names (all of them when it names none).
+type Listener struct {
+	Name          string    `yaml:"name" required:"true"`
+	Address       string    `yaml:"address" required:"true"`
+	TLS           TLSConfig `yaml:"tls,omitempty"`
+	ProxyProtocol bool      `yaml:"proxy_protocol,omitempty"`
+}
+
+// GetListeners returns the configured listeners, or a single unnamed
+// listener on the top-level address, tls and proxy_protocol settings when
+// there are none
+func (s *ServerConfig) GetListeners() []Listener {
+	if len(s.Listeners) == 0 {
+		return []Listener{{Address: s.Address, TLS: s.TLS, ProxyProtocol: s.ProxyProtocol}}
+	}
+	return s.Listeners
+}
+
+// Client authentication modes accepted by tls.client_auth
+const (
+	ClientAuthNone             = "none"               // Do not ask for a client certificate
+	ClientAuthRequest          = "request"            // Ask for one but accept any or none
+	ClientAuthRequire          = "require"            // Require one but do not verify it
+	ClientAuthVerifyIfGiven    = "verify_if_given"    // Verify a certificate if one is sent
+	ClientAuthRequireAndVerify = "require_and_verify" // Require a certificate signed by client_ca_file
+)
+
+// TLSConfig enables HTTPS on the listener. The certificate comes from
+// CertFile and KeyFile, or is generated at startup when SelfSigned is set.
+type TLSConfig struct {
+	CertFile        string   `yaml:"cert_file,omitempty"`
+	KeyFile         string   `yaml:"key_file,omitempty"`
+	SelfSigned      bool     `yaml:"self_signed,omitempty"`
+	SelfSignedHosts []string `yaml:"self_signed_hosts,omitempty"` // Extra DNS names and IPs for the generated certificate
+	ClientCAFile    string   `yaml:"client_ca_file,omitempty"`
+	ClientAuth      string   `yaml:"client_auth,omitempty" default:"none"`
+}
+
+// IsEnabled reports whether the listener serves HTTPS
+func (t *TLSConfig) IsEnabled() bool {
+	return t.CertFile != "" || t.SelfSigned
+}
+
+// GetClientAuth returns the client authentication mode, defaulting to "none"
+func (t *TLSConfig) GetClientAuth() string {
+	if t.ClientAuth == "" {
+		return defaultClientAuth
+	}
+	return strings.ToLower(t.ClientAuth)
+}
+
+// RequestIDConfig controls how requests are identified. The ID is read
+// from Header, or generated when the request has none, and is returned in
+// the same response header.
+type RequestIDConfig struct {
+	Header string `yaml:"header,omitempty" default:"X-Request-ID"`
+}
+
+// GetHeader returns the request ID header name, defaulting to X-Request-ID
+func (r *RequestIDConfig) GetHeader() string {
+	if r.Header == "" {
+		return defaultRequestIDHeader
+	}
+	return r.Header
+}
+
+// TracingConfig controls OpenTelemetry tracing of route requests. Spans are
+// exported over OTLP/HTTP to Endpoint, which is either host:port or a full
+// URL such as http://collector:4318/v1/traces.
+type TracingConfig struct {
+	Enabled     bool              `yaml:"enabled,omitempty"`
+	Endpoint    string            `yaml:"endpoint,omitempty" default:"localhost:4318"`
+	Insecure    bool              `yaml:"insecure,omitempty"`
+	Headers     map[string]string `yaml:"headers,omitempty"`
+	ServiceName string            `yaml:"service_name,omitempty" default:"echo2"`
+	SampleRatio float64           `yaml:"sample_ratio,omitempty" default:"1"`
+	EchoHeaders bool              `yaml:"echo_headers,omitempty"`
+}
+
+// Names of the request controls, which are also the query parameters they
+// are read from by default
+const (
+	ControlDelay       = "delay"
+	ControlStatus      = "status"
+	ControlHeader      = "header"
+	ControlSize        = "size"
+	ControlContentType = "content_type"
+	ControlFailRate    = "fail_rate"
+)
+
+// ControlsConfig controls how clients change a single response, for example
+// with ?status=503. The delay control is always enabled, the others need
+// Enabled. Each control can be renamed or moved to a request header.
+type ControlsConfig struct {
+	Enabled     bool          `yaml:"enabled,omitempty"`
+	MaxSize     string        `yaml:"max_size,omitempty" default:"10MB"` // Largest body the size control generates
+	Delay       ControlSource `yaml:"delay,omitempty"`
+	Status      ControlSource `yaml:"status,omitempty"`
+	Header      ControlSource `yaml:"header,omitempty"`
+	Size        ControlSource `yaml:"size,omitempty"`
+	ContentType ControlSource `yaml:"content_type,omitempty"`
+	FailRate    ControlSource `yaml:"fail_rate,omitempty"`
+}
+
+// ControlSource names the query parameter and request header a control is
+// read from. With neither set, the control is read from the query parameter
+// named after it.
+type ControlSource struct {
+	Param  string `yaml:"param,omitempty"`  // Query parameter
+	Header string `yaml:"header,omitempty"` // Request header, which wins over the query parameter
+}
+
+// GetNames returns the query parameter and header the control is read from,
+// defaulting to the query parameter name. Either may be empty.
+func (c *ControlSource) GetNames(name string) (param, header string) {
+	if c.Param == "" && c.Header == "" {
+		return name, ""
+	}
+	return c.Param, c.Header
+}
+
+// GetMaxSize returns the largest body the size control generates in bytes,
+// defaulting to 10MB
+func (c *ControlsConfig) GetMaxSize() int64 {
+	value := c.MaxSize
+	if value == "" {
+		value = defaultControlsMaxSize
+	}
+	// The size was validated when the config was loaded
+	size, _ := ParseSize(value)
+	return size
+}
+
+// sources returns every control's source by control name
+func (c *ControlsConfig) sources() map[string]*ControlSource {
+	return map[string]*ControlSource{
+		ControlDelay:       &c.Delay,
+		ControlStatus:      &c.Status,
+		ControlHeader:      &c.Header,
+		ControlSize:        &c.Size,
+		ControlContentType: &c.ContentType,
+		ControlFailRate:    &c.FailRate,
+	}
 }
 
 // Route represents a single route configuration
 type Route struct {
-	Path           string            `yaml:"path"`
-	Method         string            `yaml:"method,omitempty"`
-	ResponseBody   string            `yaml:"response_body,omitempty"`
-	ResponseHeader map[string]string `yaml:"response_header,omitempty"`
-	ResponseStatus int               `yaml:"response_status,omitempty"`
-	ResponseDump   bool              `yaml:"response_dump,omitempty"`
-	Conditions     []RouteCondition  `yaml:"conditions,omitempty"`
+	Path             string                  `yaml:"path" required:"true"`
+	Method           string                  `yaml:"method,omitempty" default:"GET"`
+	ResponseBody     string                  `yaml:"response_body,omitempty"`
+	ResponseHeader   map[string]HeaderValues `yaml:"response_header,omitempty"`
+	ResponseCookies  []Cookie                `yaml:"response_cookies,omitempty"`
+	ResponseStatus   int                     `yaml:"response_status,omitempty" default:"200"`
+	ResponseDump     bool                    `yaml:"response_dump,omitempty"`
+	DumpFormat       string                  `yaml:"dump_format,omitempty"` // json, yaml, http or html, chosen from the Accept header when empty
+	ResponseEcho     EchoConfig              `yaml:"response_echo,omitempty"`
+	ResponseGenerate GenerateConfig          `yaml:"response_generate,omitempty"`
+	Conditions       []RouteCondition        `yaml:"conditions,omitempty"`
+	Listeners        []string                `yaml:"listeners,omitempty"` // Names of the listeners serving the route, all when empty
+	Host             string                  `yaml:"host,omitempty"`      // Host pattern such as api.example.test or *.partner.test, any host when empty
+
+	source string // File and line the route was loaded from, empty when built in code
 }
 
-// RouteCondition represents a conditional response based on header matching
+// RouteCondition represents a conditional response based on header and
+// cookie matching, the client address and, for TLS requests, the client
+// certificate
 type RouteCondition struct {
-	HeaderMatch    map[string]string `yaml:"header_match"`
-	ResponseBody   string            `yaml:"response_body,omitempty"`
-	ResponseHeader map[string]string `yaml:"response_header,omitempty"`
-	ResponseStatus int               `yaml:"response_status,omitempty"`
+	HeaderMatch     map[string]string       `yaml:"header_match"`
+	CookieMatch     map[string]string       `yaml:"cookie_match,omitempty"`
+	ClientCert      *ClientCertMatch        `yaml:"client_cert,omitempty"`
+	ClientIP        *ClientIPMatch          `yaml:"client_ip,omitempty"`
+	ResponseBody    string                  `yaml:"response_body,omitempty"`
+	ResponseHeader  map[string]HeaderValues `yaml:"response_header,omitempty"`
+	ResponseCookies []Cookie                `yaml:"response_cookies,omitempty"`
+	ResponseStatus  int                     `yaml:"response_status,omitempty" default:"200"`
+}
+
+// HeaderValues holds the values of a response header, each sent as its own
+// header line. In the config it is a single string or a list of strings.
+type HeaderValues []string
+
+// UnmarshalYAML accepts a single value as well as a list of values
+func (h *HeaderValues) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*h = HeaderValues{node.Value}
+		return nil
+	}
+	var values []string
+	if err := node.Decode(&values); err != nil {
+		return err
+	}
+	*h = values
+	return nil
+}
+
+// SameSite values accepted by a cookie's same_site
+const (
+	SameSiteLax    = "lax"
+	SameSiteStrict = "strict"
+	SameSiteNone   = "none"
+)
+
+// Cookie is a cookie sent in its own Set-Cookie response header
+type Cookie struct {
+	Name     string `yaml:"name" required:"true"`
+	Value    string `yaml:"value,omitempty"`
+	Path     string `yaml:"path,omitempty"`
+	Domain   string `yaml:"domain,omitempty"`
+	MaxAge   int    `yaml:"max_age,omitempty"`   // Seconds until the cookie expires, negative to delete it
+	Expires  string `yaml:"expires,omitempty"`   // Duration from the response time such as 24h, or an HTTP date
+	HTTPOnly bool   `yaml:"http_only,omitempty"` // Hide the cookie from JavaScript
+	Secure   bool   `yaml:"secure,omitempty"`    // Only send the cookie over HTTPS
+	SameSite string `yaml:"same_site,omitempty"` // lax, strict or none
+}
+
+// ExpiresAt returns when the cookie expires for a response sent at now, or
+// the zero time when Expires is not set
+func (c *Cookie) ExpiresAt(now time.Time) (time.Time, error) {
+	if c.Expires == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(c.Expires); err == nil {
+		return now.Add(d), nil
+	}
+	t, err := http.ParseTime(c.Expires)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expires '%s', expected a duration such as 24h or an HTTP date", c.Expires)
+	}
+	return t, nil
+}
+
+// EchoConfig makes a route answer with the request body and its
+// Content-Type instead of the configured body. The transformations apply in
+// field order: Patch, then Uppercase, then Envelope.
+type EchoConfig struct {
+	Enabled   bool                 `yaml:"enabled,omitempty"`
+	Patch     []JSONPatchOperation `yaml:"patch,omitempty"`     // RFC 6902 operations applied to a JSON body
+	Uppercase bool                 `yaml:"uppercase,omitempty"` // Uppercase a UTF-8 body
+	Envelope  bool                 `yaml:"envelope,omitempty"`  // Wrap the body in a JSON object with request metadata
+}
+
+// Body types accepted by response_generate.type
+const (
+	GenerateRandom = "random" // Random bytes
+	GenerateText   = "text"   // Text repeated up to the size
+	GenerateLorem  = "lorem"  // Lorem ipsum sentences
+	GenerateJSON   = "json"   // A JSON array of objects following a schema
+)
+
+// GenerateTypes lists the body types response_generate can produce
+var GenerateTypes = []string{GenerateRandom, GenerateText, GenerateLorem, GenerateJSON}
+
+// Field types accepted in response_generate.schema
+const (
+	FieldString = "string" // Random alphanumeric string
+	FieldInt    = "int"    // Random non-negative integer
+	FieldFloat  = "float"  // Random number with two decimals
+	FieldBool   = "bool"   // Random boolean
+	FieldUUID   = "uuid"   // Random version 4 UUID
+	FieldTime   = "time"   // Random RFC 3339 timestamp within the past year
+	FieldIndex  = "index"  // Position of the object in the array, starting at 1
+)
+
+// FieldTypes lists the field types of a generated JSON object
+var FieldTypes = []string{FieldString, FieldInt, FieldFloat, FieldBool, FieldUUID, FieldTime, FieldIndex}
+
+// GenerateConfig makes a route answer with a generated body instead of
+// response_body, for bodies too large to keep in the config. Random, text and
+// lorem bodies have a fixed Size; json bodies hold Count objects.
+type GenerateConfig struct {
+	Type   string            `yaml:"type,omitempty"`   // random, text, lorem or json
+	Size   string            `yaml:"size,omitempty"`   // Body size such as 10MB for random, text and lorem
+	Text   string            `yaml:"text,omitempty"`   // Text the text type repeats, a filler line when empty
+	Count  int               `yaml:"count,omitempty"`  // Number of objects of the json type
+	Schema map[string]string `yaml:"schema,omitempty"` // Field names and types of the json objects, id and name when empty
+}
+
+// IsEnabled reports whether the route generates its body
+func (g *GenerateConfig) IsEnabled() bool {
+	return g.Type != ""
+}
+
+// GetSize returns the size of a random, text or lorem body in bytes
+func (g *GenerateConfig) GetSize() int64 {
+	// The size was validated when the config was loaded
+	size, _ := ParseSize(g.Size)
+	return size
+}
+
+// GetSchema returns the field types of generated JSON objects, defaulting to
+// an index id and a string name
+func (g *GenerateConfig) GetSchema() map[string]string {
+	if len(g.Schema) == 0 {
+		return map[string]string{"id": FieldIndex, "name": FieldString}
+	}
+	return g.Schema
+}
+
+// JSONPatchOperation is a single RFC 6902 JSON Patch operation
+type JSONPatchOperation struct {
+	Op    string `yaml:"op" required:"true"`   // add, remove, replace, move, copy or test
+	Path  string `yaml:"path" required:"true"` // JSON Pointer to the target, such as /items/0
+	From  string `yaml:"from,omitempty"`       // JSON Pointer to the source of move and copy
+	Value any    `yaml:"value,omitempty"`      // Value of add, replace and test, null when omitted
+}
+
+// ClientCertMatch lists requirements on the client certificate of a TLS
+// request. Every field that is set must match.
+type ClientCertMatch struct {
+	Subject    string `yaml:"subject,omitempty"`     // Full subject, e.g. "CN=partner,O=Acme"
+	CommonName string `yaml:"common_name,omitempty"` // Subject common name
+	SAN        string `yaml:"san,omitempty"`         // Any DNS, email, IP or URI subject alternative name
+}
+
+// ClientIPMatch restricts a condition to client addresses. Entries are IPs
+// or CIDRs such as 10.0.0.0/8. The client must be in Allow, when set, and
+// must not be in Deny.
+type ClientIPMatch struct {
+	Allow []string `yaml:"allow,omitempty"`
+	Deny  []string `yaml:"deny,omitempty"`
+
+	allow, deny []netip.Prefix // Allow and Deny, parsed when the config is validated
 }
 
 // GetMethod returns the HTTP method for the route, defaulting to GET
 func (r *Route) GetMethod() string {
 	if r.Method == "" {
-		return http.MethodGet
+		return defaultMethod
 	}
 	return r.Method
 }
 
+// ServedOn reports whether the route is served on the named listener
+func (r *Route) ServedOn(listener string) bool {
+	return len(r.Listeners) == 0 || slices.Contains(r.Listeners, listener)
+}
+
+// Source returns the file and line the route was declared at (e.g.
+// "mocks/payments.yaml:12"), or an empty string for routes built in code
+func (r *Route) Source() string {
+	return r.source
+}
+
+// describe names the route at index i for error messages, including its
+// source location when known
+func (r *Route) describe(i int) string {
+	if r.source == "" {
+		return fmt.Sprintf("route %d", i)
+	}
+	return fmt.Sprintf("route %d (%s)", i, r.source)
+}
+
 // GetResponseBody returns the response body, defaulting to empty string
 func (r *Route) GetResponseBody() string {
 	return r.ResponseBody
 }
 
 // GetResponseHeaders returns the response headers, defaulting to empty map
-func (r *Route) GetResponseHeaders() map[string]string {
+func (r *Route) GetResponseHeaders() map[string]HeaderValues {
 	if r.ResponseHeader == nil {
-		return make(map[string]string)
+		return make(map[string]HeaderValues)
 	}
 	return r.ResponseHeader
 }
 
+// GetResponseCookies returns the cookies set on the response
+func (r *Route) GetResponseCookies() []Cookie {
+	return r.ResponseCookies
+}
+
 // GetResponseStatus returns the response status code, defaulting to 200
 func (r *Route) GetResponseStatus() int {
 	if r.ResponseStatus == 0 {
-		return 200
+		return defaultResponseStatus
 	}
 	return r.ResponseStatus
 }
@@ -66,34 +473,56 @@ func (r *Route) GetResponseDump() bool {
 	return r.ResponseDump
 }
 
+// GetDumpFormat returns the request dump format in lower case, or an empty
+// string when the format is negotiated from the request's Accept header
+func (r *Route) GetDumpFormat() string {
+	return strings.ToLower(r.DumpFormat)
+}
+
 // GetResponseBody returns the response body for a condition, defaulting to empty string
 func (c *RouteCondition) GetResponseBody() string {
 	return c.ResponseBody
 }
 
 // GetResponseHeaders returns the response headers for a condition, defaulting to empty map
-func (c *RouteCondition) GetResponseHeaders() map[string]string {
+func (c *RouteCondition) GetResponseHeaders() map[string]HeaderValues {
 	if c.ResponseHeader == nil {
-		return make(map[string]string)
+		return make(map[string]HeaderValues)
 	}
 	return c.ResponseHeader
 }
 
+// GetResponseCookies returns the cookies set on the response for a condition
+func (c *RouteCondition) GetResponseCookies() []Cookie {
+	return c.ResponseCookies
+}
+
 // GetResponseStatus returns the response status code for a condition, defaulting to 200
 func (c *RouteCondition) GetResponseStatus() int {
 	if c.ResponseStatus == 0 {
-		return 200
+		return defaultConditionResponseStatus
 	}
 	return c.ResponseStatus
 }
 
 // MatchesHeaders checks if the condition's header requirements match the request headers
 func (c *RouteCondition) MatchesHeaders(requestHeaders map[string]string) bool {
+	values := make(map[string][]string, len(requestHeaders))
+	for key, value := range requestHeaders {
+		values[key] = []string{value}
+	}
+	return c.MatchesHeaderValues(values)
+}
+
+// MatchesHeaderValues checks if the condition's header requirements match
+// the request headers. A repeated request header matches when any of its
+// values does.
+func (c *RouteCondition) MatchesHeaderValues(requestHeaders map[string][]string) bool {
 	for expectedKey, expectedValue := range c.HeaderMatch {
 		found := false
-		for actualKey, actualValue := range requestHeaders {
+		for actualKey, actualValues := range requestHeaders {
 			// Case-insensitive header name comparison
-			if strings.EqualFold(expectedKey, actualKey) && actualValue == expectedValue {
+			if strings.EqualFold(expectedKey, actualKey) && slices.Contains(actualValues, expectedValue) {
 				found = true
 				break
 			}
@@ -105,10 +534,29 @@ func (c *RouteCondition) MatchesHeaders(requestHeaders map[string]string) bool {
 	return true
 }
 
+// MatchesCookies checks if the condition's cookie requirements match the
+// request cookies. Cookie names are case-sensitive.
+func (c *RouteCondition) MatchesCookies(requestCookies map[string]string) bool {
+	for name, expectedValue := range c.CookieMatch {
+		if value, ok := requestCookies[name]; !ok || value != expectedValue {
+			return false
+		}
+	}
+	return true
+}
+
 // GetLogLevel returns the log level, defaulting to "info"
 func (s *ServerConfig) GetLogLevel() string {
 	if s.LogLevel == "" {
-		return "info"
+		return defaultLogLevel
 	}
 	return strings.ToLower(s.LogLevel)
 }
+
+// GetLogFormat returns the log format, defaulting to "text"
+func (s *ServerConfig) GetLogFormat() string {
+	if s.LogFormat == "" {
+		return defaultLogFormat
+	}
+	return strings.ToLower(s.LogFormat)
+}
@@ -2,7 +2,9 @@ package configs
 
 import (
 	"net/http"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestRoute_GetMethod(t *testing.T) {
@@ -68,27 +70,29 @@ func TestRoute_GetResponseHeaders(t *testing.T) {
 	tests := []struct {
 		name     string
 		route    Route
-		expected map[string]string
+		expected map[string]HeaderValues
 	}{
 		{
 			name:     "nil headers should return empty map",
 			route:    Route{ResponseHeader: nil},
-			expected: make(map[string]string),
+			expected: make(map[string]HeaderValues),
 		},
 		{
 			name:     "empty headers map",
-			route:    Route{ResponseHeader: make(map[string]string)},
-			expected: make(map[string]string),
+			route:    Route{ResponseHeader: make(map[string]HeaderValues)},
+			expected: make(map[string]HeaderValues),
 		},
 		{
 			name: "headers with content",
-			route: Route{ResponseHeader: map[string]string{
-				"Content-Type": "application/json",
-				"X-Custom":     "value",
+			route: Route{ResponseHeader: map[string]HeaderValues{
+				"Content-Type": {"application/json"},
+				"X-Custom":     {"value"},
+				"Link":         {"</a>; rel=preload", "</b>; rel=preload"},
 			}},
-			expected: map[string]string{
-				"Content-Type": "application/json",
-				"X-Custom":     "value",
+			expected: map[string]HeaderValues{
+				"Content-Type": {"application/json"},
+				"X-Custom":     {"value"},
+				"Link":         {"</a>; rel=preload", "</b>; rel=preload"},
 			},
 		},
 	}
@@ -102,7 +106,7 @@ func TestRoute_GetResponseHeaders(t *testing.T) {
 			}
 
 			for key, expectedValue := range tt.expected {
-				if gotValue, exists := got[key]; !exists || gotValue != expectedValue {
+				if gotValue, exists := got[key]; !exists || !slices.Equal(gotValue, expectedValue) {
 					t.Errorf("Route.GetResponseHeaders()[%s] = %v, want %v", key, gotValue, expectedValue)
 				}
 			}
@@ -315,6 +319,84 @@ func TestRouteCondition_MatchesHeaders(t *testing.T) {
 	}
 }
 
+func TestRouteCondition_MatchesHeaderValues(t *testing.T) {
+	condition := RouteCondition{HeaderMatch: map[string]string{"X-Feature": "beta"}}
+
+	tests := []struct {
+		name     string
+		headers  map[string][]string
+		expected bool
+	}{
+		{"single value", map[string][]string{"X-Feature": {"beta"}}, true},
+		{"any value of a repeated header", map[string][]string{"x-feature": {"alpha", "beta"}}, true},
+		{"no value matches", map[string][]string{"X-Feature": {"alpha", "gamma"}}, false},
+		{"header missing", map[string][]string{"X-Other": {"beta"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := condition.MatchesHeaderValues(tt.headers); got != tt.expected {
+				t.Errorf("RouteCondition.MatchesHeaderValues() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRouteCondition_MatchesCookies(t *testing.T) {
+	condition := RouteCondition{CookieMatch: map[string]string{"session": "abc123"}}
+
+	tests := []struct {
+		name     string
+		cookies  map[string]string
+		expected bool
+	}{
+		{"matching cookie", map[string]string{"session": "abc123", "theme": "dark"}, true},
+		{"different value", map[string]string{"session": "other"}, false},
+		{"missing cookie", map[string]string{"theme": "dark"}, false},
+		{"names are case-sensitive", map[string]string{"Session": "abc123"}, false},
+		{"no cookies", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := condition.MatchesCookies(tt.cookies); got != tt.expected {
+				t.Errorf("RouteCondition.MatchesCookies() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	if !(&RouteCondition{}).MatchesCookies(nil) {
+		t.Error("Expected a condition without cookie_match to match any request")
+	}
+}
+
+func TestCookie_ExpiresAt(t *testing.T) {
+	now := time.Date(2025, 9, 17, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expires  string
+		expected time.Time
+		err      bool
+	}{
+		{"", time.Time{}, false},
+		{"24h", now.Add(24 * time.Hour), false},
+		{"Wed, 21 Oct 2026 07:28:00 GMT", time.Date(2026, 10, 21, 7, 28, 0, 0, time.UTC), false},
+		{"tomorrow", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		cookie := Cookie{Name: "session", Expires: tt.expires}
+		got, err := cookie.ExpiresAt(now)
+		if (err != nil) != tt.err {
+			t.Errorf("ExpiresAt(%q): unexpected error %v", tt.expires, err)
+			continue
+		}
+		if !got.Equal(tt.expected) {
+			t.Errorf("ExpiresAt(%q) = %v, want %v", tt.expires, got, tt.expected)
+		}
+	}
+}
+
 func TestServerConfig_GetLogLevel(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -398,3 +480,35 @@ func TestRoute_GetResponseDump(t *testing.T) {
 		})
 	}
 }
+
+func TestControlSource_GetNames(t *testing.T) {
+	tests := []struct {
+		name           string
+		source         ControlSource
+		expectedParam  string
+		expectedHeader string
+	}{
+		{"default query parameter", ControlSource{}, "status", ""},
+		{"renamed parameter", ControlSource{Param: "code"}, "code", ""},
+		{"moved to a header", ControlSource{Header: "X-Echo-Status"}, "", "X-Echo-Status"},
+		{"parameter and header", ControlSource{Param: "code", Header: "X-Echo-Status"}, "code", "X-Echo-Status"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param, header := tt.source.GetNames(ControlStatus)
+			if param != tt.expectedParam || header != tt.expectedHeader {
+				t.Errorf("GetNames() = %q, %q, want %q, %q", param, header, tt.expectedParam, tt.expectedHeader)
+			}
+		})
+	}
+}
+
+func TestControlsConfig_GetMaxSize(t *testing.T) {
+	if got := (&ControlsConfig{}).GetMaxSize(); got != 10<<20 {
+		t.Errorf("Expected a default of 10MB, got %d", got)
+	}
+	if got := (&ControlsConfig{MaxSize: "512KB"}).GetMaxSize(); got != 512<<10 {
+		t.Errorf("Expected 512KB, got %d", got)
+	}
+}